@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*****************
+ * Punto de control y reanudación (-checkpoint, -resume)
+ *****************/
+
+// checkpoint registra lo que ya llegó a la salida en una ejecución que no se
+// completó: las plataformas terminadas y los programas con activos escritos.
+// Con -resume la siguiente ejecución omite ambos y, como la salida se abre en
+// modo append, añade solo lo que faltaba; por eso solo se admite con los
+// formatos de una línea por activo (ver formatAppends).
+type checkpoint struct {
+	mu       sync.Mutex
+	done     map[string]bool
+	programs map[programKey]bool
+	// resumed indica que se cargó un punto de control no vacío: la ejecución
+	// no verá todo el scope aunque termine sin errores.
+	resumed bool
+	skipped int
+}
+
+type checkpointFile struct {
+	Done     []string `json:"platforms_done"`
+	Programs []string `json:"programs"`
+}
+
+func newCheckpoint() *checkpoint {
+	return &checkpoint{done: make(map[string]bool), programs: make(map[programKey]bool)}
+}
+
+// loadCheckpoint lee el punto de control de path para -resume. Si no existe
+// (la ejecución anterior se completó) no hay nada que omitir.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	cp := newCheckpoint()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("-resume: %w", err)
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("-resume: %s: %w", path, err)
+	}
+	for _, label := range f.Done {
+		cp.done[label] = true
+	}
+	for _, p := range f.Programs {
+		platform, handle, _ := strings.Cut(p, "/")
+		cp.programs[programKey{platform, handle}] = true
+	}
+	cp.resumed = len(f.Done) > 0 || len(f.Programs) > 0
+	return cp, nil
+}
+
+// platformDone indica si la plataforma (etiqueta del trabajo) terminó en la
+// ejecución anterior.
+func (cp *checkpoint) platformDone(label string) bool {
+	return cp.done[label]
+}
+
+// skipFunc devuelve la función con la que un fetcher omite, sin
+// descargarlos, los programas de platform ya escritos; nil si no se reanuda.
+func (cp *checkpoint) skipFunc(platform string) func(handle string) bool {
+	if cp == nil || !cp.resumed {
+		return nil
+	}
+	// Solo cuenta lo cargado del punto de control: lo registrado en esta
+	// ejecución lo decide wrap.
+	previous := make(map[string]bool)
+	for k := range cp.programs {
+		if k.platform == platform {
+			previous[k.handle] = true
+		}
+	}
+	return func(handle string) bool { return previous[handle] }
+}
+
+// wrap descarta los activos de los programas ya escritos en la ejecución
+// anterior (las plataformas que no saben omitirlos los vuelven a descargar) y
+// registra los nuevos.
+func (cp *checkpoint) wrap(next AssetWriter) AssetWriter {
+	seen := make(map[programKey]bool)
+	return assetWriterFunc(func(a Asset) error {
+		k := programKey{a.Platform, a.Handle}
+		cp.mu.Lock()
+		if cp.programs[k] && !seen[k] {
+			cp.skipped++
+			cp.mu.Unlock()
+			return nil
+		}
+		seen[k] = true
+		cp.programs[k] = true
+		cp.mu.Unlock()
+		return next.WriteAsset(a)
+	})
+}
+
+// save escribe el punto de control en path, añadiendo a done las plataformas
+// terminadas en esta ejecución.
+func (cp *checkpoint) save(path string, finished []string) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	for _, label := range finished {
+		cp.done[label] = true
+	}
+	var f checkpointFile
+	for label := range cp.done {
+		f.Done = append(f.Done, label)
+	}
+	for k := range cp.programs {
+		f.Programs = append(f.Programs, k.platform+"/"+k.handle)
+	}
+	sort.Strings(f.Done)
+	sort.Strings(f.Programs)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sabb-checkpoint-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+/*****************
+ * Colores en stderr
+ *****************/
+
+// colorizer envuelve texto en secuencias ANSI cuando está habilitado.
+type colorizer struct{ enabled bool }
+
+// stderrColor colorea los mensajes de progreso y errores de stderr. Se
+// configura en main con newStderrColorizer.
+var stderrColor colorizer
+
+// newStderrColorizer habilita el color solo si stderr es una terminal, no se
+// pidió -no-color y no está definida NO_COLOR (https://no-color.org).
+func newStderrColorizer(noColor bool) colorizer {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return colorizer{}
+	}
+	return colorizer{enabled: term.IsTerminal(int(os.Stderr.Fd()))}
+}
+
+func (c colorizer) wrap(code, s string) string {
+	if !c.enabled {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func (c colorizer) cyan(s string) string  { return c.wrap("36", s) }
+func (c colorizer) red(s string) string   { return c.wrap("31", s) }
+func (c colorizer) green(s string) string { return c.wrap("32", s) }
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*****************
+ * Archivo de configuración (-config)
+ *****************/
+
+// El archivo de configuración es YAML y sus claves son los nombres de los
+// flags, p. ej.:
+//
+//	program: hackerone,bugcrowd
+//	username: hacker
+//	apikey: ...
+//	output: ~/scopes/all.jsonl
+//	concurrency: 8
+//	notify:
+//	  - slack:https://hooks.slack.com/...
+//	serve:
+//	  store: sqlite:scopes.db
+//
+// Las claves del nivel superior son los flags de fetch; las de una sección
+// con el nombre de otra orden (serve, export, query...) son los flags de esa
+// orden. La línea de comandos tiene prioridad sobre el archivo.
+//
+// La sección profiles define perfiles con nombre, normalmente una cuenta por
+// perfil, que -profile aplica sobre el nivel superior:
+//
+//	profile: personal   # perfil por defecto
+//	profiles:
+//	  work:
+//	    username: hacker-corp
+//	    apikey: ...
+//	  personal:
+//	    username: hacker
+//	    apikey: ...
+
+// defaultConfigPath es ~/.config/sabb/config.yaml, o el equivalente bajo
+// $XDG_CONFIG_HOME si está definido.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "sabb", "config.yaml")
+}
+
+// configFlag registra -config en set.
+func configFlag(set *flag.FlagSet) *string {
+	return set.String("config", "", "Archivo de configuración YAML (por defecto ~/.config/sabb/config.yaml, si existe)")
+}
+
+// loadConfig lee el archivo de configuración. Sin path se usa el de por
+// defecto, que puede no existir; un path explícito tiene que existir.
+func loadConfig(path string) (cfg map[string]any, name string, err error) {
+	explicit := path != ""
+	if !explicit {
+		if path = defaultConfigPath(); path == "" {
+			return nil, "", nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("-config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, "", fmt.Errorf("-config: %s: %w", path, err)
+	}
+	warnConfigPermissions(path, cfg)
+	return cfg, path, nil
+}
+
+// applyConfig asigna a los flags de set que no se indicaron en la línea de
+// comandos los valores de la sección section del archivo de configuración
+// ("" para el nivel superior, que corresponde a fetch). En el nivel superior,
+// el perfil de -profile (o de la clave profile) tiene prioridad sobre el
+// resto del archivo.
+func applyConfig(set *flag.FlagSet, path, section string) error {
+	cfg, name, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	profile := ""
+	if f := set.Lookup("profile"); f != nil && section == "" {
+		profile = f.Value.String()
+	}
+	if cfg == nil {
+		if profile != "" {
+			return fmt.Errorf("-profile %s: no hay archivo de configuración (%s)", profile, defaultConfigPath())
+		}
+		return nil
+	}
+	if section != "" {
+		values, _ := cfg[section].(map[string]any)
+		return setFlags(set, values, name)
+	}
+
+	// Con -all-profiles no se aplica el perfil por defecto: se usan todos.
+	if all := set.Lookup("all-profiles"); profile == "" && (all == nil || all.Value.String() != "true") {
+		profile, _ = cfg["profile"].(string)
+	}
+	values := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		values[k] = v
+	}
+	if profile != "" {
+		p, err := configProfile(cfg, profile, name)
+		if err != nil {
+			return err
+		}
+		for k, v := range p {
+			values[k] = v
+		}
+		values["profile"] = profile
+	}
+	return setFlags(set, values, name)
+}
+
+// configProfile devuelve el perfil name de cfg.
+func configProfile(cfg map[string]any, name, source string) (map[string]any, error) {
+	profiles, _ := cfg["profiles"].(map[string]any)
+	v, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: el perfil %q no existe", source, name)
+	}
+	p, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: el perfil %q no es una sección", source, name)
+	}
+	return p, nil
+}
+
+// profileCredentialKeys son las claves de un perfil que usa -all-profiles.
+var profileCredentialKeys = map[string]bool{
+	"username":         true,
+	"apikey":           true,
+	"credentials-file": true,
+	"intigriti-token":  true,
+	"bugcrowd-token":   true,
+	"yeswehack-token":  true,
+}
+
+// loadProfileCredentials devuelve las credenciales de todos los perfiles del
+// archivo de configuración, ordenados por nombre, para -all-profiles. El
+// resto de claves de cada perfil se ignora: las demás opciones son comunes a
+// toda la ejecución.
+func loadProfileCredentials(path string) ([]credentialSet, error) {
+	cfg, name, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles, _ := cfg["profiles"].(map[string]any)
+	if len(profiles) == 0 {
+		return nil, errors.New("-all-profiles: la configuración no define perfiles (profiles:)")
+	}
+	names := make([]string, 0, len(profiles))
+	for n := range profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var sets []credentialSet
+	for _, n := range names {
+		p, err := configProfile(cfg, n, name)
+		if err != nil {
+			return nil, err
+		}
+		get := func(key string) string {
+			if list, ok := p[key].([]any); ok {
+				items := make([]string, len(list))
+				for i, item := range list {
+					items[i] = configString(item)
+				}
+				return strings.Join(items, ",")
+			}
+			if v, ok := p[key]; ok && v != nil {
+				return configString(v)
+			}
+			return ""
+		}
+		for key := range p {
+			if !profileCredentialKeys[key] {
+				log.Printf("aviso: -all-profiles solo usa las credenciales de los perfiles; se ignora %s en %s", key, n)
+			}
+		}
+		set, err := newCredentialSet(n, get("username"), get("apikey"), get("credentials-file"), map[string]string{
+			"intigriti": get("intigriti-token"),
+			"bugcrowd":  get("bugcrowd-token"),
+			"yeswehack": get("yeswehack-token"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("perfil %s: %w", n, err)
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// setFlags asigna values a los flags de set no indicados en la línea de
+// comandos. source identifica el origen de los valores en los errores.
+func setFlags(set *flag.FlagSet, values map[string]any, source string) error {
+	explicit := make(map[string]bool)
+	set.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for key, v := range values {
+		// Las secciones son de otras órdenes: ningún flag recibe un mapa.
+		if _, ok := v.(map[string]any); ok {
+			continue
+		}
+		f := set.Lookup(key)
+		if f == nil || key == "config" {
+			return fmt.Errorf("%s: opción desconocida %q", source, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		if err := setFlagValue(f, v); err != nil {
+			return fmt.Errorf("%s: %s: %w", source, key, err)
+		}
+	}
+	return nil
+}
+
+// setFlagValue asigna un valor YAML a f. Las listas se asignan elemento a
+// elemento en los flags repetibles (-notify) y unidas por comas en el resto
+// (-program, -username...).
+func setFlagValue(f *flag.Flag, v any) error {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = configString(item)
+		}
+		if _, repeatable := f.Value.(*stringList); repeatable {
+			for _, item := range items {
+				if err := f.Value.Set(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return f.Value.Set(strings.Join(items, ","))
+	}
+	return f.Value.Set(configString(v))
+}
+
+// configString convierte un escalar YAML en el texto del flag, expandiendo
+// "~/" al directorio personal en las rutas.
+func configString(v any) string {
+	s := fmt.Sprint(v)
+	if rest, ok := strings.CutPrefix(s, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return s
+}
+
+// configWarned son los archivos de los que ya se avisó: -all-profiles lee la
+// configuración dos veces.
+var configWarned = make(map[string]bool)
+
+// warnConfigPermissions avisa si un archivo con credenciales pueden leerlo
+// otros usuarios.
+func warnConfigPermissions(path string, cfg map[string]any) {
+	if runtime.GOOS == "windows" || !hasConfigSecrets(cfg) || configWarned[path] {
+		return
+	}
+	configWarned[path] = true
+	if fi, err := os.Stat(path); err == nil && fi.Mode().Perm()&0077 != 0 {
+		log.Printf("aviso: %s contiene credenciales y otros usuarios pueden leerlo (chmod 600 %s)", path, path)
+	}
+}
+
+func hasConfigSecrets(cfg map[string]any) bool {
+	for key, v := range cfg {
+		if sub, ok := v.(map[string]any); ok {
+			if hasConfigSecrets(sub) {
+				return true
+			}
+			continue
+		}
+		for _, secret := range []string{"apikey", "token", "password"} {
+			if strings.Contains(key, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Credenciales HackerOne
+ *****************/
+
+// ErrInvalidCredentials indica credenciales ausentes o mal formadas; ver
+// platforms.ErrInvalidCredentials.
+var ErrInvalidCredentials = platforms.ErrInvalidCredentials
+
+// credentialsUsage es la ayuda que se muestra junto a ErrInvalidCredentials.
+const credentialsUsage = "uso: sabb -username <usuario> -apikey <token>, SABB_H1_USERNAME y SABB_H1_APIKEY, sabb auth login hackerone, o -credentials-file con una línea username:apikey por cuenta"
+
+// credentialEnv son las variables de entorno con credenciales y el flag que
+// sustituyen. Tienen prioridad sobre los flags y el archivo de configuración,
+// para que en CI y en contenedores los secretos no aparezcan en los
+// argumentos del proceso.
+var credentialEnv = []struct{ name, flag string }{
+	{"SABB_H1_USERNAME", "username"},
+	{"SABB_H1_APIKEY", "apikey"},
+	{"SABB_INTIGRITI_TOKEN", "intigriti-token"},
+	{"SABB_BUGCROWD_TOKEN", "bugcrowd-token"},
+	{"SABB_YESWEHACK_TOKEN", "yeswehack-token"},
+	{"SABB_SMTP_PASSWORD", "smtp-password"},
+}
+
+// applyCredentialEnv asigna a los flags de set las credenciales definidas en
+// el entorno; las variables vacías se ignoran.
+func applyCredentialEnv(set *flag.FlagSet) error {
+	for _, e := range credentialEnv {
+		v := os.Getenv(e.name)
+		if v == "" {
+			continue
+		}
+		if err := set.Set(e.flag, v); err != nil {
+			return fmt.Errorf("%s: %w", e.name, err)
+		}
+	}
+	return nil
+}
+
+// hackerOneAccount es un par username/apikey de HackerOne.
+type hackerOneAccount struct {
+	username string
+	key      string
+}
+
+// credentials devuelve las credenciales tipadas que espera hackerone.Fetcher.
+func (a hackerOneAccount) credentials() Credentials {
+	return Credentials{Username: a.username, Token: a.key}
+}
+
+// credentialSet son las credenciales de una ejecución: las de los flags o,
+// con -all-profiles, las de cada perfil de la configuración.
+type credentialSet struct {
+	// profile es el nombre del perfil; vacío con las credenciales de los flags.
+	profile  string
+	accounts []hackerOneAccount
+	// key es -apikey saneada: el token de las plataformas sin uno propio.
+	key    string
+	tokens map[string]string
+}
+
+// newCredentialSet reúne las credenciales de un perfil (o de los flags con
+// profile vacío). tokens son los tokens propios de cada plataforma.
+func newCredentialSet(profile, usernames, keys, file string, tokens map[string]string) (credentialSet, error) {
+	accounts, err := parseHackerOneAccounts(usernames, keys, file)
+	if err != nil {
+		return credentialSet{}, err
+	}
+	set := credentialSet{profile: profile, accounts: accounts, key: sanitizeKey(keys), tokens: make(map[string]string)}
+	for p, t := range tokens {
+		if t != "" {
+			set.tokens[p] = sanitizeKey(t)
+		}
+	}
+	return set, nil
+}
+
+// needsHackerOnePrompt indica si hay que pedir por terminal la cuenta de
+// HackerOne: solo cuando hackerone está entre programs y no tiene cuenta ni
+// -apikey. Los tokens de otras plataformas no cuentan.
+func (c credentialSet) needsHackerOnePrompt(programs []string) bool {
+	if len(c.accounts) > 0 || c.key != "" {
+		return false
+	}
+	for _, p := range programs {
+		if strings.ToLower(strings.TrimSpace(p)) == "hackerone" {
+			return true
+		}
+	}
+	return false
+}
+
+// token devuelve el token de platform: el suyo propio o, si no, key.
+func (c credentialSet) token(platform string) string {
+	if t := c.tokens[platform]; t != "" {
+		return t
+	}
+	return c.key
+}
+
+// mergeCredentialAccounts reúne las cuentas de HackerOne de sets, sin repetir
+// las que comparten usuario y clave, y devuelve también la primera -apikey no
+// vacía, con la que se valida HackerOne cuando no hay cuentas.
+func mergeCredentialAccounts(sets []credentialSet) (accounts []hackerOneAccount, key string) {
+	seen := make(map[hackerOneAccount]bool)
+	for _, set := range sets {
+		for _, acc := range set.accounts {
+			if !seen[acc] {
+				seen[acc] = true
+				accounts = append(accounts, acc)
+			}
+		}
+		if key == "" {
+			key = set.key
+		}
+	}
+	return accounts, key
+}
+
+// parseHackerOneAccounts combina las cuentas indicadas por flags y por archivo.
+// -username y -apikey aceptan listas separadas por comas que se emparejan por
+// posición; el archivo contiene una línea username:apikey por cuenta.
+func parseHackerOneAccounts(usernames, keys, file string) ([]hackerOneAccount, error) {
+	var accounts []hackerOneAccount
+
+	if usernames != "" || keys != "" {
+		users := splitList(usernames)
+		apiKeys := splitList(keys)
+		// Sin -username se acepta -apikey en la forma username:apikey. Si falta
+		// una de las dos partes no se empareja nada aquí: la validación por
+		// plataforma (validatePlatformCredentials) indica qué falta, y un token
+		// suelto puede ser para otra plataforma.
+		switch {
+		case len(users) == 0 && allCombined(apiKeys):
+			for i, k := range apiKeys {
+				user, key, _ := strings.Cut(k, ":")
+				users = append(users, user)
+				apiKeys[i] = key
+			}
+		case len(users) == 0:
+			apiKeys = nil
+		case len(apiKeys) == 0:
+			apiKeys = make([]string, len(users))
+		}
+		if len(users) != len(apiKeys) {
+			return nil, fmt.Errorf("%w: se recibieron %d usernames y %d apikeys; deben emparejarse uno a uno", ErrInvalidCredentials, len(users), len(apiKeys))
+		}
+		for i := range users {
+			accounts = append(accounts, hackerOneAccount{username: users[i], key: apiKeys[i]})
+		}
+	}
+
+	if file != "" {
+		fromFile, err := readCredentialsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, fromFile...)
+	}
+
+	return accounts, nil
+}
+
+// readCredentialsFile lee un archivo con una línea username:apikey por cuenta.
+// Se ignoran las líneas vacías y las que empiezan por '#'.
+func readCredentialsFile(path string) ([]hackerOneAccount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el archivo de credenciales: %w", err)
+	}
+	defer f.Close()
+
+	var accounts []hackerOneAccount
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || sanitizeKey(parts[0]) == "" || sanitizeKey(parts[1]) == "" {
+			return nil, fmt.Errorf("%w: %s:%d: formato inválido, debe ser username:apikey", ErrInvalidCredentials, path, n)
+		}
+		accounts = append(accounts, hackerOneAccount{
+			username: sanitizeKey(parts[0]),
+			key:      sanitizeKey(parts[1]),
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+	return accounts, nil
+}
+
+// allCombined indica si todas las claves tienen la forma username:apikey con
+// ambas partes no vacías.
+func allCombined(keys []string) bool {
+	for _, k := range keys {
+		user, key, ok := strings.Cut(k, ":")
+		if !ok || user == "" || key == "" {
+			return false
+		}
+	}
+	return len(keys) > 0
+}
+
+// validatePlatformCredentials comprueba antes de empezar que las credenciales
+// tienen la forma que espera la plataforma, para fallar con un mensaje preciso
+// en lugar de con un error de la API a mitad de la ejecución.
+func validatePlatformCredentials(platform string, c Credentials) error {
+	if isPublicPlatform(platform) {
+		return nil
+	}
+	switch platform {
+	case "hackerone":
+		switch {
+		case c.Username == "" && c.Token == "":
+			return fmt.Errorf("%w: hackerone requiere username y apikey", ErrInvalidCredentials)
+		case c.Username == "":
+			return fmt.Errorf("%w: hackerone requiere username:apikey y falta el username (usa -username o -apikey username:apikey)", ErrInvalidCredentials)
+		case c.Token == "":
+			return fmt.Errorf("%w: hackerone requiere username:apikey y falta la apikey", ErrInvalidCredentials)
+		case strings.Contains(c.Token, ":"):
+			return fmt.Errorf("%w: la apikey de hackerone contiene ':'; con -username indica solo el token", ErrInvalidCredentials)
+		}
+	default:
+		switch {
+		case c.Token == "":
+			return fmt.Errorf("%w: %s requiere un token no vacío (-apikey)", ErrInvalidCredentials, platform)
+		case strings.Contains(c.Token, ":"):
+			return fmt.Errorf("%w: %s espera un token simple, no username:apikey", ErrInvalidCredentials, platform)
+		}
+	}
+	return nil
+}
+
+// splitList separa una lista por comas y sanea cada elemento.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = sanitizeKey(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// canPrompt indica si se pueden pedir credenciales de forma interactiva: solo
+// cuando stdin es una terminal, para no quedar esperando en CI o con stdin
+// redirigido.
+func canPrompt() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// promptHackerOneAccount pide por terminal el username (si no se indicó) y la
+// apikey, esta última sin eco para que no quede en pantalla ni en el historial.
+func promptHackerOneAccount(username string) (hackerOneAccount, error) {
+	if username == "" {
+		fmt.Fprint(os.Stderr, "HackerOne username: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return hackerOneAccount{}, fmt.Errorf("no se pudo leer el username: %w", err)
+		}
+		username = line
+	}
+	fmt.Fprint(os.Stderr, "HackerOne API key: ")
+	key, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return hackerOneAccount{}, fmt.Errorf("no se pudo leer la apikey: %w", err)
+	}
+	return hackerOneAccount{username: sanitizeKey(username), key: sanitizeKey(string(key))}, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// malformedHackerOneKeys son valores de -apikey sin username:apikey completo.
+var malformedHackerOneKeys = []struct{ name, apikey string }{
+	{"sin dos puntos", "tokenonly"},
+	{"sin username", ":key"},
+	{"sin apikey", "user:"},
+}
+
+func TestMalformedHackerOneKey(t *testing.T) {
+	for _, tc := range malformedHackerOneKeys {
+		t.Run(tc.name, func(t *testing.T) {
+			// Igual que fetchOnce: sin cuentas se valida el token suelto.
+			accounts, err := parseHackerOneAccounts("", tc.apikey, "")
+			if err == nil {
+				creds := Credentials{Token: sanitizeKey(tc.apikey)}
+				if len(accounts) > 0 {
+					creds = accounts[0].credentials()
+				}
+				err = validatePlatformCredentials("hackerone", creds)
+			}
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("-apikey %q: error = %v, se esperaba ErrInvalidCredentials", tc.apikey, err)
+			}
+			if code := exitCode(err); code != exitInvalidCredentials {
+				t.Errorf("-apikey %q: código de salida %d, se esperaba %d", tc.apikey, code, exitInvalidCredentials)
+			}
+		})
+	}
+}
+
+// TestMalformedHackerOneKeyExitCode ejecuta sabb de verdad (el propio binario
+// de test, con SABB_TEST_MAIN) y comprueba el código de salida del proceso.
+func TestMalformedHackerOneKeyExitCode(t *testing.T) {
+	if args := os.Getenv("SABB_TEST_MAIN"); args != "" {
+		os.Args = append([]string{"sabb"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	for _, tc := range malformedHackerOneKeys {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			args := []string{"-program", "hackerone", "-apikey", tc.apikey, "-output", filepath.Join(dir, "out.txt")}
+			cmd := exec.Command(os.Args[0], "-test.run=^TestMalformedHackerOneKeyExitCode$")
+			cmd.Env = []string{
+				"SABB_TEST_MAIN=" + strings.Join(args, "\n"),
+				"HOME=" + dir,
+				"XDG_CONFIG_HOME=" + dir,
+				"PATH=" + dir,
+			}
+			out, err := cmd.CombinedOutput()
+			var exit *exec.ExitError
+			if !errors.As(err, &exit) || exit.ExitCode() != exitInvalidCredentials {
+				t.Fatalf("-apikey %q: %v, se esperaba código %d\n%s", tc.apikey, err, exitInvalidCredentials, out)
+			}
+		})
+	}
+}
+
+func TestNonHackerOneRunDoesNotPrompt(t *testing.T) {
+	set, err := newCredentialSet("", "", "", "", map[string]string{"bugcrowd": "X"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.needsHackerOnePrompt([]string{"bugcrowd"}) {
+		t.Error("-program bugcrowd -bugcrowd-token X: se pediría la cuenta de HackerOne")
+	}
+	if got := set.token("bugcrowd"); got != "X" {
+		t.Errorf("token de bugcrowd = %q, se esperaba X", got)
+	}
+	if !set.needsHackerOnePrompt([]string{"bugcrowd", " HackerOne"}) {
+		t.Error("con hackerone seleccionado y sin cuenta se esperaba pedirla")
+	}
+
+	// La cuenta pedida no debe usarse como token de otras plataformas.
+	set.accounts = []hackerOneAccount{{username: "hacker", key: "h1key"}}
+	for _, p := range []string{"intigriti", "yeswehack"} {
+		if got := set.token(p); got != "" {
+			t.Errorf("token de %s = %q, se esperaba vacío", p, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+/*****************
+ * Volcado de tráfico (-debug-dump)
+ *****************/
+
+// dumpTransport guarda cada solicitud y su respuesta cruda en un archivo de
+// dir, con la cabecera Authorization redactada para que los volcados puedan
+// compartirse en reportes de bugs.
+type dumpTransport struct {
+	dir  string
+	next http.RoundTripper
+	seq  atomic.Int64
+}
+
+func newDumpTransport(dir string, next http.RoundTripper) (*dumpTransport, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &dumpTransport{dir: dir, next: next}, nil
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var buf bytes.Buffer
+
+	redacted := req.Clone(req.Context())
+	if redacted.Header.Get("Authorization") != "" {
+		redacted.Header.Set("Authorization", "[REDACTED]")
+	}
+	if dump, err := httputil.DumpRequestOut(redacted, false); err == nil {
+		buf.Write(dump)
+	} else {
+		fmt.Fprintf(&buf, "%s %s\n(no se pudo volcar la solicitud: %v)\n", req.Method, req.URL, err)
+	}
+	buf.WriteString("\n")
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		fmt.Fprintf(&buf, "ERROR: %v\n", err)
+	} else if dump, derr := httputil.DumpResponse(resp, true); derr == nil {
+		buf.Write(dump)
+	} else {
+		fmt.Fprintf(&buf, "(no se pudo volcar la respuesta: %v)\n", derr)
+	}
+
+	name := fmt.Sprintf("%s-%04d.http", time.Now().Format("20060102T150405.000000000"), t.seq.Add(1))
+	if werr := os.WriteFile(filepath.Join(t.dir, name), buf.Bytes(), 0600); werr != nil {
+		log.Printf("aviso: no se pudo escribir el volcado %s: %v", name, werr)
+	}
+	return resp, err
+}
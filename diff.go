@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+/*****************
+ * Cambios respecto a la ejecución anterior (-diff)
+ *****************/
+
+// programKey identifica un programa en todas las plataformas.
+type programKey struct {
+	platform string
+	handle   string
+}
+
+func (k programKey) String() string {
+	if k.platform == "" && k.handle == "" {
+		return "(sin programa)"
+	}
+	return k.platform + "/" + k.handle
+}
+
+// scopeDiff compara los activos de esta ejecución con los de una salida
+// anterior. Con una salida jsonl se comparan programa a programa; con una
+// salida de texto (un identificador por línea) no hay programas y se compara
+// todo como un único grupo.
+type scopeDiff struct {
+	mu   sync.Mutex
+	flat bool
+	// baseline indica que no hay ejecución anterior: solo se registran los
+	// activos para compararlos en la siguiente consulta de -watch.
+	baseline bool
+	previous map[programKey]map[string]Asset
+	current  map[programKey]map[string]Asset
+}
+
+func newScopeDiff() *scopeDiff {
+	return &scopeDiff{
+		previous: make(map[programKey]map[string]Asset),
+		current:  make(map[programKey]map[string]Asset),
+	}
+}
+
+// programChange son los cambios de un programa entre las dos ejecuciones.
+type programChange struct {
+	key     programKey
+	added   []Asset
+	removed []Asset
+	// isNew y gone indican que el programa entero apareció o desapareció.
+	isNew bool
+	gone  bool
+}
+
+// loadScopeDiff lee la salida anterior en path. Un archivo que empieza por
+// '[' se lee como -format json-grouped; en el resto, las líneas que empiezan
+// por '{' se leen como -format jsonl y las demás como identificadores sueltos.
+func loadScopeDiff(path string) (*scopeDiff, error) {
+	if f := formatFromOutput(path); !formatAppends(f) && f != "json-grouped" {
+		return nil, fmt.Errorf("-diff: %s: no se puede comparar una salida %s; usa jsonl, json-grouped o txt", path, f)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("-diff: %w", err)
+	}
+
+	d := newScopeDiff()
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var programs []groupedProgram
+		if err := json.Unmarshal(data, &programs); err != nil {
+			return nil, fmt.Errorf("-diff: %s: %w", path, err)
+		}
+		for _, p := range programs {
+			for _, ga := range p.Assets {
+				a := Asset{Platform: p.Platform, Handle: p.Handle, OffersBounties: p.OffersBounties,
+					Identifier: ga.Identifier, Type: ga.Type, Instruction: ga.Instruction}
+				addDiffAsset(d.previous, programKey{a.Platform, a.Handle}, a)
+			}
+		}
+		return d, nil
+	}
+
+	d.flat = true
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var a Asset
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), &a); err != nil {
+				return nil, fmt.Errorf("-diff: %s:%d: %w", path, n, err)
+			}
+			d.flat = false
+		} else {
+			a.Identifier = line
+		}
+		addDiffAsset(d.previous, programKey{a.Platform, a.Handle}, a)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("-diff: %w", err)
+	}
+	return d, nil
+}
+
+// sameFile indica si las rutas a y b son el mismo archivo existente.
+func sameFile(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+func addDiffAsset(m map[programKey]map[string]Asset, k programKey, a Asset) {
+	assets, ok := m[k]
+	if !ok {
+		assets = make(map[string]Asset)
+		m[k] = assets
+	}
+	assets[a.Identifier] = a
+}
+
+// wrap registra cada activo de esta ejecución antes de pasarlo a next.
+func (d *scopeDiff) wrap(next AssetWriter) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		k := programKey{a.Platform, a.Handle}
+		if d.flat {
+			k = programKey{}
+		}
+		d.mu.Lock()
+		addDiffAsset(d.current, k, a)
+		d.mu.Unlock()
+		return next.WriteAsset(a)
+	})
+}
+
+// changes devuelve los programas con cambios, ordenados. Los activos
+// eliminados solo se calculan si withRemoved; y, con programas, solo para las
+// plataformas de ran o con activos en esta ejecución, para no dar por
+// eliminado todo lo de una plataforma que esta vez no se consultó.
+func (d *scopeDiff) changes(withRemoved bool, ran map[string]bool) []programChange {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	keys := make(map[programKey]bool)
+	seen := make(map[string]bool, len(ran))
+	for p := range ran {
+		seen[p] = true
+	}
+	for k := range d.current {
+		keys[k] = true
+		seen[k.platform] = true
+	}
+	if withRemoved {
+		for k := range d.previous {
+			if d.flat || seen[k.platform] {
+				keys[k] = true
+			}
+		}
+	}
+
+	var out []programChange
+	for k := range keys {
+		prev, cur := d.previous[k], d.current[k]
+		c := programChange{key: k, isNew: prev == nil && !d.flat, gone: cur == nil && !d.flat}
+		for id, a := range cur {
+			if _, ok := prev[id]; !ok {
+				c.added = append(c.added, a)
+			}
+		}
+		if withRemoved {
+			for id, a := range prev {
+				if _, ok := cur[id]; !ok {
+					c.removed = append(c.removed, a)
+				}
+			}
+		}
+		if len(c.added) == 0 && len(c.removed) == 0 {
+			continue
+		}
+		sortAssetsByIdentifier(c.added)
+		sortAssetsByIdentifier(c.removed)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].key.platform != out[j].key.platform {
+			return out[i].key.platform < out[j].key.platform
+		}
+		return out[i].key.handle < out[j].key.handle
+	})
+	return out
+}
+
+// next devuelve el scopeDiff de la siguiente consulta de -watch, que compara
+// con los activos de esta. Si esta no se completó se conservan también los
+// anteriores, para no dar por eliminado (y después por nuevo) lo que solo
+// faltó esta vez.
+func (d *scopeDiff) next(complete bool) *scopeDiff {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := newScopeDiff()
+	n.flat = d.flat
+	if !complete {
+		for k, assets := range d.previous {
+			for _, a := range assets {
+				addDiffAsset(n.previous, k, a)
+			}
+		}
+	}
+	for k, assets := range d.current {
+		for _, a := range assets {
+			addDiffAsset(n.previous, k, a)
+		}
+	}
+	return n
+}
+
+// writeRemoved escribe en path los activos eliminados de changes, en el
+// formato que corresponde a su extensión: los de líneas se añaden a lo que ya
+// tenga y los de documento completo lo reescriben con los de esta ejecución.
+func writeRemoved(path string, changes []programChange, mode os.FileMode) error {
+	removed := 0
+	for _, c := range changes {
+		removed += len(c.removed)
+	}
+	if removed == 0 {
+		return nil
+	}
+	format := formatFromOutput(path)
+	f, err := openOutputFile(path, mode, formatAppends(format))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc, err := newAssetEncoder(format, w, encoderOptions{})
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		for _, a := range c.removed {
+			if err := enc.WriteAsset(a); err != nil {
+				return err
+			}
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func sortAssetsByIdentifier(assets []Asset) {
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Identifier < assets[j].Identifier })
+}
+
+// printDiff escribe los cambios agrupados por programa, con los colores de
+// stderr: '+' para los activos nuevos y '-' para los eliminados.
+func printDiff(w io.Writer, path string, changes []programChange) {
+	writeDiff(w, path, changes, stderrColor)
+}
+
+// writeDiff es printDiff con los colores de color, que pueden estar
+// desactivados para escribir texto plano.
+func writeDiff(w io.Writer, path string, changes []programChange, color colorizer) {
+	added, removed := 0, 0
+	for _, c := range changes {
+		added += len(c.added)
+		removed += len(c.removed)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "Sin cambios respecto a %s\n", path)
+		return
+	}
+	fmt.Fprintf(w, "Cambios respecto a %s: %d activos nuevos, %d eliminados\n", path, added, removed)
+	for _, c := range changes {
+		switch {
+		case c.isNew:
+			fmt.Fprintf(w, "%s (programa nuevo)\n", c.key)
+		case c.gone:
+			fmt.Fprintf(w, "%s (programa eliminado)\n", c.key)
+		default:
+			fmt.Fprintf(w, "%s\n", c.key)
+		}
+		for _, a := range c.added {
+			fmt.Fprintln(w, color.green("  + "+a.Identifier))
+		}
+		for _, a := range c.removed {
+			fmt.Fprintln(w, color.red("  - "+a.Identifier))
+		}
+	}
+}
+
+/*****************
+ * Comparación de dos salidas (sabb diff)
+ *****************/
+
+// runDiff implementa "sabb diff <anterior> <actual>": compara dos salidas ya
+// escritas (jsonl o txt) sin consultar las plataformas. Si alguna es de texto
+// se comparan solo los identificadores.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb diff <anterior> <actual>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("diff: se esperan dos archivos")
+	}
+	d, err := loadScopeDiff(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cur, err := loadScopeDiff(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	d.current = cur.previous
+	if d.flat || cur.flat {
+		d.flat = true
+		d.previous = flattenDiffAssets(d.previous)
+		d.current = flattenDiffAssets(d.current)
+	}
+	// Las dos salidas están completas: una plataforma que falte en la actual
+	// se ha eliminado entera.
+	ran := make(map[string]bool)
+	for k := range d.previous {
+		ran[k.platform] = true
+	}
+	color := colorizer{enabled: os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd()))}
+	writeDiff(os.Stdout, fs.Arg(0), d.changes(true, ran), color)
+	return nil
+}
+
+// flattenDiffAssets junta los activos de todos los programas en un único grupo.
+func flattenDiffAssets(m map[programKey]map[string]Asset) map[programKey]map[string]Asset {
+	flat := make(map[programKey]map[string]Asset)
+	for _, assets := range m {
+		for _, a := range assets {
+			addDiffAsset(flat, programKey{}, a)
+		}
+	}
+	return flat
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*****************
+ * Feed Atom de cambios de scope (-feed)
+ *****************/
+
+// maxFeedEntries limita las entradas que conserva el feed; las más antiguas
+// se descartan.
+const maxFeedEntries = 200
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Category atomCategory `xml:"category"`
+	Content  atomContent  `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// writeFeed añade al feed Atom de path una entrada por cada programa con
+// cambios, por delante de las que ya tenía. El archivo se reemplaza de una vez
+// para que un lector nunca vea un feed a medio escribir.
+func writeFeed(path string, changes []programChange, now time.Time, mode os.FileMode) error {
+	feed := atomFeed{
+		ID:     "urn:sabb:feed",
+		Title:  "sabb: cambios de scope",
+		Author: atomAuthor{Name: "sabb"},
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return fmt.Errorf("%s no es un feed Atom: %w", path, err)
+		}
+	}
+	// Sin cambios basta con que el feed exista para poder suscribirse.
+	if len(changes) == 0 && err == nil {
+		return nil
+	}
+
+	updated := now.UTC().Format(time.RFC3339)
+	entries := make([]atomEntry, 0, len(changes)+len(feed.Entries))
+	for _, c := range changes {
+		entries = append(entries, feedEntry(c, now))
+	}
+	entries = append(entries, feed.Entries...)
+	if len(entries) > maxFeedEntries {
+		entries = entries[:maxFeedEntries]
+	}
+	feed.Entries = entries
+	feed.Updated = updated
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sabb-feed-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(xml.Header); err == nil {
+		_, err = tmp.Write(append(out, '\n'))
+	}
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// feedEntry describe los cambios de un programa: título con el recuento y
+// contenido con un activo por línea, como -diff.
+func feedEntry(c programChange, now time.Time) atomEntry {
+	title := fmt.Sprintf("%s: %d activos nuevos, %d eliminados", c.key, len(c.added), len(c.removed))
+	switch {
+	case c.isNew:
+		title = fmt.Sprintf("%s: programa nuevo con %d activos", c.key, len(c.added))
+	case c.gone:
+		title = fmt.Sprintf("%s: programa eliminado", c.key)
+	}
+	var b strings.Builder
+	for _, a := range c.added {
+		fmt.Fprintf(&b, "+ %s\n", a.Identifier)
+	}
+	for _, a := range c.removed {
+		fmt.Fprintf(&b, "- %s\n", a.Identifier)
+	}
+	return atomEntry{
+		ID:       fmt.Sprintf("urn:sabb:%s:%s:%d", url.PathEscape(c.key.platform), url.PathEscape(c.key.handle), now.UnixNano()),
+		Title:    title,
+		Updated:  now.UTC().Format(time.RFC3339),
+		Category: atomCategory{Term: c.key.platform},
+		Content:  atomContent{Type: "text", Body: b.String()},
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*****************
+ * Respuestas desde fixtures locales (-fixtures)
+ *****************/
+
+// fixtureTransport sirve las respuestas de la API desde archivos locales en
+// vez de la red. La ruta de cada URL se resuelve con fixturePath; si el
+// archivo no existe se responde 404 para que el error sea visible.
+type fixtureTransport struct {
+	dir string
+}
+
+// fixturePath resuelve una URL a su archivo: <dir>/<path>.json, o
+// <dir>/<path>/<query>.json si la URL lleva query. Los listados y los scopes
+// se piden siempre paginados, así que cada página es un archivo. Por ejemplo:
+//
+//	/v1/hackers/programs?page[number]=1&page[size]=100                        -> v1/hackers/programs/page[number]=1&page[size]=100.json
+//	/v1/hackers/programs/acme/structured_scopes?page[number]=2&page[size]=100 -> v1/hackers/programs/acme/structured_scopes/page[number]=2&page[size]=100.json
+//	/v1/hackers/programs/acme                                                 -> v1/hackers/programs/acme.json
+func fixturePath(dir string, u *url.URL) string {
+	p := strings.Trim(u.Path, "/")
+	if u.RawQuery != "" {
+		q, err := url.QueryUnescape(u.RawQuery)
+		if err != nil {
+			q = u.RawQuery
+		}
+		p += "/" + strings.ReplaceAll(q, "/", "_")
+	}
+	return filepath.Join(dir, filepath.FromSlash(p)+".json")
+}
+
+// graphQLFixturePath resuelve una consulta GraphQL enviada por POST a
+// <dir>/<path>/<operationName>.json, o <dir>/<path>/<operationName>/<variables>.json
+// con las variables como query ordenada. Por ejemplo:
+//
+//	POST /graphql {"operationName":"TeamAssets","variables":{"handle":"acme"}} -> graphql/TeamAssets/handle=acme.json
+func graphQLFixturePath(dir string, u *url.URL, body []byte) (string, bool) {
+	var q struct {
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &q); err != nil || q.OperationName == "" {
+		return "", false
+	}
+	vars := url.Values{}
+	for k, v := range q.Variables {
+		if v != nil {
+			vars.Set(k, fmt.Sprint(v))
+		}
+	}
+	p := strings.Trim(u.Path, "/") + "/" + q.OperationName
+	if len(vars) > 0 {
+		v, _ := url.QueryUnescape(vars.Encode())
+		p += "/" + strings.ReplaceAll(v, "/", "_")
+	}
+	return filepath.Join(dir, filepath.FromSlash(p)+".json"), true
+}
+
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := fixturePath(t.dir, req.URL)
+	if req.Method == http.MethodPost && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if p, ok := graphQLFixturePath(t.dir, req.URL, body); ok {
+			path = p
+		}
+	}
+	data, err := os.ReadFile(path)
+	status := http.StatusOK
+	switch {
+	case os.IsNotExist(err):
+		status = http.StatusNotFound
+		data = []byte(fmt.Sprintf(`{"error":"fixture no encontrada: %s"}`, path))
+	case err != nil:
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s (fixture %s)", status, http.StatusText(status), path),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
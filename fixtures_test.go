@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/hackerone"
+)
+
+func TestFixturePathPaginatedScope(t *testing.T) {
+	u, _ := url.Parse("https://api.hackerone.com/v1/hackers/programs/acme/structured_scopes?page[number]=2&page[size]=100")
+	want := filepath.Join("fx", "v1", "hackers", "programs", "acme", "structured_scopes", "page[number]=2&page[size]=100.json")
+	if got := fixturePath("fx", u); got != want {
+		t.Errorf("fixturePath = %s, se esperaba %s", got, want)
+	}
+}
+
+// TestFixturesPaginatedScope recorre con -fixtures un scope de dos páginas
+// enlazadas con links.next, como lo pide fetchEligibleAssets.
+func TestFixturesPaginatedScope(t *testing.T) {
+	dir := t.TempDir()
+	scopes := filepath.Join(dir, "v1", "hackers", "programs", "acme", "structured_scopes")
+	files := map[string]string{
+		filepath.Join(dir, "v1", "hackers", "programs", "acme.json"): `{"attributes":{"offers_bounties":true}}`,
+		filepath.Join(scopes, "page[number]=1&page[size]=100.json"): `{"data":[
+			{"attributes":{"asset_identifier":"*.acme.com","asset_type":"WILDCARD","eligible_for_bounty":true,"eligible_for_submission":true}}
+		],"links":{"next":"https://api.hackerone.com/v1/hackers/programs/acme/structured_scopes?page[number]=2&page[size]=100"}}`,
+		filepath.Join(scopes, "page[number]=2&page[size]=100.json"): `{"data":[
+			{"attributes":{"asset_identifier":"api.acme.com","asset_type":"URL","eligible_for_bounty":true,"eligible_for_submission":true}}
+		],"links":{}}`,
+	}
+	for path, body := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := hackerone.Fetcher{
+		Client:      &http.Client{Transport: fixtureTransport{dir: dir}},
+		Handle:      "acme",
+		Eligibility: hackerone.EligibilityBounty,
+	}
+	var got []string
+	_, err := h.Fetch(context.Background(), Credentials{Username: "fixtures", Token: "fixtures"}, assetWriterFunc(func(a Asset) error {
+		got = append(got, a.Identifier)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if fmt.Sprint(got) != "[*.acme.com api.acme.com]" {
+		t.Errorf("activos = %v, se esperaban los de las dos páginas", got)
+	}
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Formatos de salida (-format)
+ *****************/
+
+// assetEncoder serializa activos en un formato de salida concreto.
+type assetEncoder interface {
+	AssetWriter
+	// Close escribe lo que el formato tenga pendiente, p. ej. las tablas que
+	// necesitan todos los datos para alinearse.
+	Close() error
+}
+
+// encoderOptions ajusta el comportamiento de algunos formatos.
+type encoderOptions struct {
+	// expandWildcards convierte *.example.com en https://example.com en el
+	// formato urls en lugar de dejar el wildcard tal cual.
+	expandWildcards bool
+	// exclusions recoge el out_of_scope para los formatos que lo incluyen
+	// (ver formatNeedsExclusions); puede ser nil.
+	exclusions *exclusionList
+}
+
+// outputFormat registra un formato de -format. Para añadir uno basta con
+// implementar assetEncoder y añadir su entrada a outputFormats.
+type outputFormat struct {
+	name    string
+	aliases []string
+	// extensions son las extensiones de -output que lo seleccionan cuando
+	// -format no se indica.
+	extensions []string
+	// exclusions indica que el formato escribe también el out_of_scope, que
+	// entonces se recoge aunque no se use -emit-exclusions.
+	exclusions bool
+	// lines indica que el formato escribe una línea por activo y admite
+	// añadirse a una salida anterior; los demás forman un documento completo.
+	lines      bool
+	newEncoder func(w io.Writer, opts encoderOptions) assetEncoder
+}
+
+// outputFormats está en el orden en que se listan en la ayuda.
+var outputFormats = []outputFormat{
+	{name: "txt", lines: true, extensions: []string{".txt"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return lineEncoder{w}
+	}},
+	{name: "markdown", aliases: []string{"md"}, extensions: []string{".md"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &markdownReportEncoder{w: w, now: time.Now, index: make(map[string]int)}
+	}},
+	{name: "markdown-table", newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &markdownEncoder{w: w}
+	}},
+	{name: "urls", lines: true, newEncoder: func(w io.Writer, opts encoderOptions) assetEncoder {
+		return &urlsEncoder{w: w, expandWildcards: opts.expandWildcards, skipped: make(map[string]int)}
+	}},
+	{name: "json-grouped", extensions: []string{".json"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &groupedJSONEncoder{w: w, index: make(map[string]int)}
+	}},
+	{name: "jsonl", aliases: []string{"ndjson"}, lines: true, extensions: []string{".jsonl", ".ndjson"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return jsonlEncoder{enc: json.NewEncoder(w), now: time.Now}
+	}},
+	{name: "csv", extensions: []string{".csv"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &csvEncoder{w: csv.NewWriter(w)}
+	}},
+	{name: "html", extensions: []string{".html", ".htm"}, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &htmlReportEncoder{w: w, now: time.Now, index: make(map[string]int)}
+	}},
+	{name: "targets", lines: true, newEncoder: func(w io.Writer, _ encoderOptions) assetEncoder {
+		return &targetsEncoder{w: w, seen: make(map[string]bool), skipped: make(map[string]int)}
+	}},
+	{name: "burp", exclusions: true, newEncoder: func(w io.Writer, opts encoderOptions) assetEncoder {
+		return &burpEncoder{w: w, exclusions: opts.exclusions, seen: make(map[scopePattern]bool), skipped: make(map[string]int)}
+	}},
+	{name: "zap", exclusions: true, extensions: []string{".context"}, newEncoder: func(w io.Writer, opts encoderOptions) assetEncoder {
+		return &zapEncoder{w: w, exclusions: opts.exclusions, seen: make(map[string]bool), skipped: make(map[string]int)}
+	}},
+}
+
+// lookupFormat busca un formato por nombre o alias; "" es txt.
+func lookupFormat(name string) (outputFormat, bool) {
+	name = strings.ToLower(name)
+	if name == "" {
+		name = "txt"
+	}
+	for _, f := range outputFormats {
+		if f.name == name || slices.Contains(f.aliases, name) {
+			return f, true
+		}
+	}
+	return outputFormat{}, false
+}
+
+// formatNames lista los formatos para la ayuda y los errores: "txt, ... o zap".
+func formatNames() string {
+	names := make([]string, len(outputFormats))
+	for i, f := range outputFormats {
+		names[i] = f.name
+	}
+	last := len(names) - 1
+	return strings.Join(names[:last], ", ") + " o " + names[last]
+}
+
+// formatNeedsExclusions indica si el formato escribe también las exclusiones.
+func formatNeedsExclusions(format string) bool {
+	f, ok := lookupFormat(format)
+	return ok && f.exclusions
+}
+
+// formatContentType es el Content-Type de cada formato, con el que serve
+// responde y con el que se suben las salidas a S3 o por HTTP.
+func formatContentType(format string) string {
+	f, _ := lookupFormat(format)
+	switch f.name {
+	case "jsonl":
+		return "application/x-ndjson"
+	case "json-grouped", "burp":
+		return "application/json"
+	case "zap":
+		return "application/xml"
+	case "csv":
+		return "text/csv; charset=utf-8"
+	case "html":
+		return "text/html; charset=utf-8"
+	case "markdown", "markdown-table":
+		return "text/markdown; charset=utf-8"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// formatAppends indica si una salida en el formato se abre en modo append. Los
+// formatos de documento completo (json-grouped, csv, html...) se reescriben:
+// añadir un segundo documento dejaría el archivo inválido.
+func formatAppends(format string) bool {
+	f, ok := lookupFormat(format)
+	return ok && f.lines
+}
+
+// newAssetEncoder devuelve el encoder del formato indicado sobre w.
+func newAssetEncoder(format string, w io.Writer, opts encoderOptions) (assetEncoder, error) {
+	f, ok := lookupFormat(format)
+	if !ok {
+		return nil, fmt.Errorf("formato desconocido %q: se admite %s", format, formatNames())
+	}
+	return f.newEncoder(w, opts), nil
+}
+
+// formatFromOutput deduce el formato a partir de la extensión del destino
+// (archivo, clave de S3 o ruta de la URL). Para stdout o una extensión
+// desconocida devuelve txt.
+func formatFromOutput(output string) string {
+	if output == "-" {
+		return "txt"
+	}
+	p := output
+	if u, err := url.Parse(output); err == nil && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "s3") {
+		p = u.Path
+	}
+	ext := strings.ToLower(path.Ext(p))
+	for _, f := range outputFormats {
+		if slices.Contains(f.extensions, ext) {
+			return f.name
+		}
+	}
+	return "txt"
+}
+
+// lineEncoder escribe un identificador de activo por línea (formato txt).
+type lineEncoder struct{ w io.Writer }
+
+func (e lineEncoder) WriteAsset(a Asset) error {
+	_, err := fmt.Fprintln(e.w, a.Identifier)
+	return err
+}
+
+func (lineEncoder) Close() error { return nil }
+
+// markdownEncoder acumula los activos y los escribe al cerrar como una única
+// tabla GitHub-flavored Markdown con columnas alineadas (markdown-table).
+type markdownEncoder struct {
+	w    io.Writer
+	rows [][]string
+}
+
+var markdownHeader = []string{"Platform", "Handle", "Asset", "Type"}
+
+func (e *markdownEncoder) WriteAsset(a Asset) error {
+	e.rows = append(e.rows, []string{
+		markdownCell(a.Platform),
+		markdownCell(a.Handle),
+		markdownCell(a.Identifier),
+		markdownCell(a.Type),
+	})
+	return nil
+}
+
+func (e *markdownEncoder) Close() error {
+	bw := bufio.NewWriter(e.w)
+	writeMarkdownTable(bw, markdownHeader, e.rows)
+	return bw.Flush()
+}
+
+// writeMarkdownTable escribe una tabla con las columnas alineadas al ancho de
+// su celda más larga.
+func writeMarkdownTable(w io.Writer, header []string, rows [][]string) {
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = max(len(h), 3)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	writeMarkdownRow(w, header, widths)
+	sep := make([]string, len(widths))
+	for i, w := range widths {
+		sep[i] = strings.Repeat("-", w)
+	}
+	writeMarkdownRow(w, sep, widths)
+	for _, row := range rows {
+		writeMarkdownRow(w, row, widths)
+	}
+}
+
+func writeMarkdownRow(w io.Writer, cells []string, widths []int) {
+	fmt.Fprint(w, "|")
+	for i, cell := range cells {
+		fmt.Fprintf(w, " %-*s |", widths[i], cell)
+	}
+	fmt.Fprintln(w)
+}
+
+// markdownCell escapa los caracteres que romperían una celda de la tabla.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// markdownReportEncoder escribe al cerrar un informe Markdown para notas o
+// wikis: una sección por plataforma y, dentro, una por programa con su tabla
+// de activos y los recuentos. Plataformas y programas siguen el orden en que
+// aparecieron.
+type markdownReportEncoder struct {
+	w        io.Writer
+	now      func() time.Time
+	programs []*groupedProgram
+	index    map[string]int // plataforma+handle -> posición en programs
+}
+
+var markdownReportHeader = []string{"Asset", "Type", "Bounty"}
+
+func (e *markdownReportEncoder) WriteAsset(a Asset) error {
+	key := a.Platform + "/" + a.Handle
+	i, ok := e.index[key]
+	if !ok {
+		i = len(e.programs)
+		e.index[key] = i
+		e.programs = append(e.programs, &groupedProgram{
+			Platform:       a.Platform,
+			Handle:         a.Handle,
+			OffersBounties: a.OffersBounties,
+		})
+	}
+	e.programs[i].Assets = append(e.programs[i].Assets, groupedAsset{
+		Identifier: a.Identifier,
+		Type:       a.Type,
+		eligible:   a.BountyEligible(),
+	})
+	return nil
+}
+
+func (e *markdownReportEncoder) Close() error {
+	var platforms []string
+	byPlatform := make(map[string][]*groupedProgram)
+	assets := make(map[string]int)
+	for _, p := range e.programs {
+		if _, ok := byPlatform[p.Platform]; !ok {
+			platforms = append(platforms, p.Platform)
+		}
+		byPlatform[p.Platform] = append(byPlatform[p.Platform], p)
+		assets[p.Platform] += len(p.Assets)
+	}
+
+	bw := bufio.NewWriter(e.w)
+	fmt.Fprintf(bw, "# Scope report\n\nFetched: %s\n", e.now().UTC().Format("2006-01-02 15:04 MST"))
+	if len(e.programs) == 0 {
+		fmt.Fprintf(bw, "\nNo assets.\n")
+	}
+	for _, platform := range platforms {
+		programs := byPlatform[platform]
+		fmt.Fprintf(bw, "\n## %s\n\n%s, %s\n", markdownCell(platform), plural(len(programs), "program"), plural(assets[platform], "asset"))
+		for _, p := range programs {
+			kind := "bounty"
+			if !p.OffersBounties {
+				kind = "VDP"
+			}
+			fmt.Fprintf(bw, "\n### %s\n\n%s, %s\n\n", markdownCell(p.Handle), kind, plural(len(p.Assets), "asset"))
+			rows := make([][]string, len(p.Assets))
+			for i, a := range p.Assets {
+				bounty := "no"
+				if a.eligible {
+					bounty = "yes"
+				}
+				rows[i] = []string{markdownCell(a.Identifier), markdownCell(a.Type), bounty}
+			}
+			writeMarkdownTable(bw, markdownReportHeader, rows)
+		}
+	}
+	return bw.Flush()
+}
+
+// plural escribe "1 asset" o "N assets".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// urlsEncoder normaliza cada activo en un objetivo listo para herramientas de
+// sondeo: las URLs sin esquema reciben https://, los CIDR e IPs pasan tal cual
+// y los wildcards se mantienen o, con expandWildcards, se reducen a su host
+// base. Los tipos sin sentido como URL (apps móviles, código fuente...) se
+// omiten y se avisa al cerrar.
+type urlsEncoder struct {
+	w               io.Writer
+	expandWildcards bool
+	skipped         map[string]int
+}
+
+func (e *urlsEncoder) WriteAsset(a Asset) error {
+	target, ok := probeTarget(a, e.expandWildcards)
+	if !ok {
+		e.skipped[a.Type]++
+		return nil
+	}
+	_, err := fmt.Fprintln(e.w, target)
+	return err
+}
+
+func (e *urlsEncoder) Close() error {
+	for typ, n := range e.skipped {
+		log.Printf("aviso: formato urls: %d activos de tipo %s omitidos", n, typ)
+	}
+	return nil
+}
+
+// probeTarget devuelve el objetivo sondeable de un activo, u ok=false si su
+// tipo no tiene representación como URL.
+func probeTarget(a Asset, expandWildcards bool) (string, bool) {
+	id := strings.TrimSpace(a.Identifier)
+	switch a.Type {
+	case platforms.AssetTypeURL, platforms.AssetTypeWildcard:
+		if host, wildcard, ok := platforms.ScopeHost(a); ok && wildcard {
+			if !expandWildcards {
+				return id, true
+			}
+			return "https://" + host, true
+		}
+		if strings.Contains(id, "://") {
+			return id, true
+		}
+		return "https://" + id, true
+	case platforms.AssetTypeCIDR, platforms.AssetTypeIPAddress:
+		return id, true
+	}
+	return "", false
+}
+
+// targetsEncoder escribe una lista para httpx, nuclei o subfinder: hosts
+// desnudos (los wildcards pierden el "*."), la URL completa solo si el activo
+// trae esquema y ruta, y CIDR e IPs tal cual. Descarta los duplicados que
+// aparecen al quitar los wildcards y omite, avisando al cerrar, los tipos que
+// no son objetivos de red.
+type targetsEncoder struct {
+	w       io.Writer
+	seen    map[string]bool
+	skipped map[string]int
+}
+
+func (e *targetsEncoder) WriteAsset(a Asset) error {
+	id := strings.TrimSpace(a.Identifier)
+	var target string
+	switch a.Type {
+	case platforms.AssetTypeURL, platforms.AssetTypeWildcard:
+		if host, _, ok := platforms.ScopeHost(a); ok {
+			target = host
+		} else if strings.Contains(id, "://") {
+			target = id
+		}
+	case platforms.AssetTypeCIDR, platforms.AssetTypeIPAddress:
+		target = id
+	}
+	if target == "" {
+		e.skipped[a.Type]++
+		return nil
+	}
+	if e.seen[target] {
+		return nil
+	}
+	e.seen[target] = true
+	_, err := fmt.Fprintln(e.w, target)
+	return err
+}
+
+func (e *targetsEncoder) Close() error {
+	for typ, n := range e.skipped {
+		log.Printf("aviso: formato targets: %d activos de tipo %s omitidos", n, typ)
+	}
+	return nil
+}
+
+// groupedJSONEncoder acumula los activos por programa y escribe al cerrar un
+// array JSON con un objeto por programa, en el orden en que aparecieron. Solo
+// ve activos, así que los programas sin activos elegibles no aparecen.
+type groupedJSONEncoder struct {
+	w        io.Writer
+	programs []*groupedProgram
+	index    map[string]int // plataforma+handle -> posición en programs
+}
+
+type groupedProgram struct {
+	Platform       string         `json:"platform"`
+	Handle         string         `json:"handle"`
+	OffersBounties bool           `json:"offers_bounties"`
+	Assets         []groupedAsset `json:"assets"`
+}
+
+type groupedAsset struct {
+	Identifier  string `json:"asset"`
+	Type        string `json:"asset_type"`
+	Instruction string `json:"instruction,omitempty"`
+	// eligible solo lo usa el informe Markdown.
+	eligible bool
+}
+
+func (e *groupedJSONEncoder) WriteAsset(a Asset) error {
+	key := a.Platform + "/" + a.Handle
+	i, ok := e.index[key]
+	if !ok {
+		i = len(e.programs)
+		e.index[key] = i
+		e.programs = append(e.programs, &groupedProgram{
+			Platform:       a.Platform,
+			Handle:         a.Handle,
+			OffersBounties: a.OffersBounties,
+		})
+	}
+	e.programs[i].Assets = append(e.programs[i].Assets, groupedAsset{
+		Identifier:  a.Identifier,
+		Type:        a.Type,
+		Instruction: a.Instruction,
+	})
+	return nil
+}
+
+func (e *groupedJSONEncoder) Close() error {
+	programs := e.programs
+	if programs == nil {
+		programs = []*groupedProgram{} // "[]" en lugar de "null"
+	}
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(programs)
+}
+
+// jsonlEncoder escribe un objeto JSON por activo y línea (JSON Lines), para
+// herramientas que procesan la salida en streaming.
+type jsonlEncoder struct {
+	enc *json.Encoder
+	now func() time.Time
+}
+
+type jsonlRecord struct {
+	Platform          string    `json:"platform"`
+	Handle            string    `json:"handle"`
+	Identifier        string    `json:"asset"`
+	Type              string    `json:"asset_type"`
+	OffersBounties    bool      `json:"offers_bounties"`
+	EligibleForBounty bool      `json:"eligible_for_bounty"`
+	Instruction       string    `json:"instruction,omitempty"`
+	FetchedAt         time.Time `json:"fetched_at"`
+}
+
+func (e jsonlEncoder) WriteAsset(a Asset) error {
+	return e.enc.Encode(jsonlRecord{
+		Platform:          a.Platform,
+		Handle:            a.Handle,
+		Identifier:        a.Identifier,
+		Type:              a.Type,
+		OffersBounties:    a.OffersBounties,
+		EligibleForBounty: a.BountyEligible(),
+		Instruction:       a.Instruction,
+		FetchedAt:         e.now().UTC().Truncate(time.Second),
+	})
+}
+
+func (jsonlEncoder) Close() error { return nil }
+
+// csvEncoder escribe una fila por activo con cabecera, lista para hojas de
+// cálculo.
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+var csvHeader = []string{"platform", "handle", "asset", "asset_type", "bounty_eligible"}
+
+func (e *csvEncoder) WriteAsset(a Asset) error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	return e.w.Write([]string{a.Platform, a.Handle, a.Identifier, a.Type, strconv.FormatBool(a.BountyEligible())})
+}
+
+// Flush vuelca las filas pendientes del writer CSV.
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// Close escribe la cabecera si no hubo activos, para que la salida sea un CSV
+// válido, y vacía el buffer del writer.
+func (e *csvEncoder) Close() error {
+	if !e.header {
+		e.header = true
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
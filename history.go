@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+/*****************
+ * Historial del almacén (sabb history)
+ *****************/
+
+const historyProgramsSQL = `SELECT platform, handle, first_seen, last_seen, removed_at FROM programs
+WHERE handle = $1 OR platform || '/' || handle = $1
+ORDER BY platform, handle`
+
+const historyAssetsSQL = `SELECT platform, handle, identifier, asset_type, first_seen, last_seen, removed_at FROM assets
+WHERE identifier = $1 OR handle = $1 OR platform || '/' || handle = $1
+ORDER BY platform, handle, first_seen, identifier`
+
+// runHistory implementa "sabb history -store <almacén> <activo|programa>":
+// muestra cuándo entraron en el scope y, si es el caso, cuándo salieron el
+// programa y los activos que coinciden. Un programa puede indicarse como
+// handle o como plataforma/handle.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	config := configFlag(fs)
+	spec := fs.String("store", "", "Almacén de -store que consultar (p. ej. sqlite:scopes.db)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb history -store <almacén> <activo|programa>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyConfig(fs, *config, "history"); err != nil {
+		return err
+	}
+	if *spec == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("history: se espera -store y un activo o programa")
+	}
+	st, err := openAssetStore(*spec)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	return printHistory(os.Stdout, st.db, fs.Arg(0))
+}
+
+func printHistory(w io.Writer, db *sql.DB, query string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	found := false
+
+	rows, err := db.Query(historyProgramsSQL, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var platform, handle string
+		var first, last time.Time
+		var removed sql.NullTime
+		if err := rows.Scan(&platform, &handle, &first, &last, &removed); err != nil {
+			return err
+		}
+		if !found {
+			fmt.Fprintln(tw, "Programa\tPrimera vez\tÚltima vez\tEliminado")
+			found = true
+		}
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\t%s\n", platform, handle, historyTime(first), historyTime(last), historyRemoved(removed))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if found {
+		fmt.Fprintln(tw)
+	}
+
+	rows, err = db.Query(historyAssetsSQL, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	header := false
+	for rows.Next() {
+		var platform, handle, identifier, typ string
+		var first, last time.Time
+		var removed sql.NullTime
+		if err := rows.Scan(&platform, &handle, &identifier, &typ, &first, &last, &removed); err != nil {
+			return err
+		}
+		if !header {
+			fmt.Fprintln(tw, "Activo\tPrograma\tTipo\tPrimera vez\tÚltima vez\tEliminado")
+			header = true
+		}
+		fmt.Fprintf(tw, "%s\t%s/%s\t%s\t%s\t%s\t%s\n", identifier, platform, handle, typ, historyTime(first), historyTime(last), historyRemoved(removed))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !found && !header {
+		return fmt.Errorf("history: %q no aparece en el almacén", query)
+	}
+	return tw.Flush()
+}
+
+func historyTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04")
+}
+
+// historyRemoved muestra removed_at, o "-" si sigue en el scope.
+func historyRemoved(t sql.NullTime) string {
+	if !t.Valid {
+		return "-"
+	}
+	return historyTime(t.Time)
+}
+
+/*****************
+ * Novedades del almacén (sabb new)
+ *****************/
+
+const newProgramsSQL = `SELECT platform, handle, first_seen FROM programs
+WHERE first_seen >= $1 AND removed_at IS NULL
+ORDER BY first_seen, platform, handle`
+
+const newAssetsSQL = `SELECT platform, handle, identifier, asset_type, first_seen FROM assets
+WHERE first_seen >= $1 AND removed_at IS NULL
+ORDER BY platform, handle, first_seen, identifier`
+
+// runNew implementa "sabb new -store <almacén> -since 7d": los programas y
+// activos vistos por primera vez en ese intervalo que siguen en el scope.
+func runNew(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	config := configFlag(fs)
+	spec := fs.String("store", "", "Almacén de -store que consultar (p. ej. sqlite:scopes.db)")
+	sinceFlag := fs.String("since", "7d", "Intervalo hacia atrás: 7d, 36h, 90m...")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb new -store <almacén> [-since 7d]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyConfig(fs, *config, "new"); err != nil {
+		return err
+	}
+	if *spec == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("new: se espera -store")
+	}
+	window, err := parseDays(*sinceFlag)
+	if err != nil || window <= 0 {
+		return fmt.Errorf("new: -since %q no válido: se espera p. ej. 7d o 36h", *sinceFlag)
+	}
+	st, err := openAssetStore(*spec)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	return printNew(os.Stdout, st.db, time.Now().UTC().Add(-window))
+}
+
+func printNew(w io.Writer, db *sql.DB, since time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	rows, err := db.Query(newProgramsSQL, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	programs := 0
+	for rows.Next() {
+		var platform, handle string
+		var first time.Time
+		if err := rows.Scan(&platform, &handle, &first); err != nil {
+			return err
+		}
+		if programs == 0 {
+			fmt.Fprintln(tw, "Programa nuevo\tPrimera vez")
+		}
+		fmt.Fprintf(tw, "%s/%s\t%s\n", platform, handle, historyTime(first))
+		programs++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if programs > 0 {
+		fmt.Fprintln(tw)
+	}
+
+	rows, err = db.Query(newAssetsSQL, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	assets := 0
+	for rows.Next() {
+		var platform, handle, identifier, typ string
+		var first time.Time
+		if err := rows.Scan(&platform, &handle, &identifier, &typ, &first); err != nil {
+			return err
+		}
+		if assets == 0 {
+			fmt.Fprintln(tw, "Activo nuevo\tPrograma\tTipo\tPrimera vez")
+		}
+		fmt.Fprintf(tw, "%s\t%s/%s\t%s\t%s\n", identifier, platform, handle, typ, historyTime(first))
+		assets++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d programas y %d activos nuevos desde %s\n", programs, assets, historyTime(since))
+	return nil
+}
+
+// parseDays es time.ParseDuration con días: "7d" o "1d12h".
+func parseDays(s string) (time.Duration, error) {
+	var days time.Duration
+	if i := strings.Index(s, "d"); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, err
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return days + d, err
+}
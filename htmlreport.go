@@ -0,0 +1,140 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"time"
+)
+
+/*****************
+ * Informe HTML (-format html)
+ *****************/
+
+// htmlReportEncoder escribe al cerrar una página HTML autocontenida (CSS y JS
+// en línea, sin recursos externos) con las tablas de programas y activos,
+// ordenables y filtrables, para quien no usa la CLI.
+type htmlReportEncoder struct {
+	w        io.Writer
+	now      func() time.Time
+	programs []*htmlReportProgram
+	index    map[string]int // plataforma+handle -> posición en programs
+	assets   []htmlReportAsset
+}
+
+// htmlReportAsset es el activo tal como lo ve la plantilla, que solo accede
+// a campos exportados.
+type htmlReportAsset struct {
+	Asset
+	Eligible bool
+}
+
+type htmlReportProgram struct {
+	Platform       string
+	Handle         string
+	OffersBounties bool
+	Assets         int
+}
+
+func (e *htmlReportEncoder) WriteAsset(a Asset) error {
+	key := a.Platform + "/" + a.Handle
+	i, ok := e.index[key]
+	if !ok {
+		i = len(e.programs)
+		e.index[key] = i
+		e.programs = append(e.programs, &htmlReportProgram{
+			Platform:       a.Platform,
+			Handle:         a.Handle,
+			OffersBounties: a.OffersBounties,
+		})
+	}
+	e.programs[i].Assets++
+	e.assets = append(e.assets, htmlReportAsset{Asset: a, Eligible: a.BountyEligible()})
+	return nil
+}
+
+func (e *htmlReportEncoder) Close() error {
+	return htmlReportTemplate.Execute(e.w, struct {
+		Fetched  string
+		Programs []*htmlReportProgram
+		Assets   []htmlReportAsset
+	}{
+		Fetched:  e.now().UTC().Format("2006-01-02 15:04 MST"),
+		Programs: e.programs,
+		Assets:   e.assets,
+	})
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Scope report</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0; }
+.meta { color: #666; margin-top: .3em; }
+input[type=search] { padding: .4em; width: 24em; margin: .5em 0; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border-bottom: 1px solid #ddd; padding: .35em .6em; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #f4f4f4; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+td.num { text-align: right; }
+.instruction { color: #555; font-size: .9em; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>Scope report</h1>
+<p class="meta">Fetched {{.Fetched}} &middot; {{len .Programs}} programs &middot; {{len .Assets}} assets</p>
+
+<h2>Programs</h2>
+<input type="search" placeholder="Filter programs" data-table="programs">
+<table id="programs">
+<thead><tr><th>Platform</th><th>Handle</th><th>Bounty</th><th>Assets</th></tr></thead>
+<tbody>
+{{- range .Programs}}
+<tr><td>{{.Platform}}</td><td>{{.Handle}}</td><td>{{if .OffersBounties}}yes{{else}}no{{end}}</td><td class="num">{{.Assets}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h2>Assets</h2>
+<input type="search" placeholder="Filter assets" data-table="assets">
+<table id="assets">
+<thead><tr><th>Platform</th><th>Handle</th><th>Asset</th><th>Type</th><th>Bounty</th><th>Instruction</th></tr></thead>
+<tbody>
+{{- range .Assets}}
+<tr><td>{{.Platform}}</td><td>{{.Handle}}</td><td>{{.Identifier}}</td><td>{{.Type}}</td><td>{{if .Eligible}}yes{{else}}no{{end}}</td><td class="instruction">{{.Instruction}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<script>
+document.querySelectorAll("input[data-table]").forEach(function (input) {
+  var rows = document.getElementById(input.dataset.table).tBodies[0].rows;
+  input.addEventListener("input", function () {
+    var q = input.value.toLowerCase();
+    for (var i = 0; i < rows.length; i++) {
+      rows[i].hidden = q !== "" && rows[i].textContent.toLowerCase().indexOf(q) < 0;
+    }
+  });
+});
+document.querySelectorAll("th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), body = table.tBodies[0];
+    var col = th.cellIndex, asc = !th.classList.contains("asc");
+    table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+    th.classList.add(asc ? "asc" : "desc");
+    var rows = Array.prototype.slice.call(body.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[col].textContent, y = b.cells[col].textContent;
+      var c = x.localeCompare(y, undefined, {numeric: true, sensitivity: "base"});
+      return asc ? c : -c;
+    });
+    rows.forEach(function (r) { body.appendChild(r); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+/*****************
+ * Caché HTTP en disco (-cache-dir)
+ *****************/
+
+// cacheTransport guarda en dir las respuestas GET que traen ETag o
+// Last-Modified y, en las siguientes ejecuciones, las revalida con
+// If-None-Match/If-Modified-Since: si la API responde 304 se sirve el cuerpo
+// guardado sin volver a descargarlo.
+type cacheTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// cacheEntry es una respuesta guardada. La clave incluye la cabecera
+// Authorization (resumida), porque cada cuenta ve programas distintos.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+func newCacheTransport(dir string, next http.RoundTripper) (*cacheTransport, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cacheTransport{dir: dir, next: next}, nil
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	path := t.path(req)
+	entry, cached := t.load(path)
+	if cached {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached:
+		resp.Body.Close()
+		// Se conservan las cabeceras del 304 (p. ej. X-RateLimit-*).
+		header := resp.Header.Clone()
+		if entry.ContentType != "" {
+			header.Set("Content-Type", entry.ContentType)
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.store(path, cacheEntry{
+			URL:          req.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			Body:         body,
+		})
+	}
+	return resp, nil
+}
+
+// path devuelve el archivo de la respuesta a req: un hash de la URL y de la
+// cabecera Authorization, que así no queda escrita en disco.
+func (t *cacheTransport) path(req *http.Request) string {
+	h := sha256.New()
+	io.WriteString(h, req.URL.String())
+	h.Write([]byte{0})
+	io.WriteString(h, req.Header.Get("Authorization"))
+	return filepath.Join(t.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (t *cacheTransport) load(path string) (cacheEntry, bool) {
+	var e cacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return e, false
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, false
+	}
+	return e, e.ETag != "" || e.LastModified != ""
+}
+
+// store guarda e de forma atómica. Los fallos solo se avisan: la caché no
+// debe interrumpir la ejecución.
+func (t *cacheTransport) store(path string, e cacheEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(t.dir, ".tmp-*")
+	if err != nil {
+		log.Printf("aviso: no se pudo escribir en la caché: %v", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("aviso: no se pudo escribir en la caché: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+/*****************
+ * Llavero del sistema (sabb auth)
+ *****************/
+
+// Las credenciales guardadas con "sabb auth login" viven en el llavero del
+// sistema (Keychain en macOS, Credential Manager en Windows, Secret Service
+// en Linux y BSD) y no en archivos en claro. Cada una se guarda bajo el
+// servicio keychainService con la cuenta <plataforma> o <plataforma>/<perfil>;
+// la de HackerOne es username:apikey.
+const keychainService = "sabb"
+
+var (
+	// errKeychainNotFound indica que el llavero no tiene la credencial.
+	errKeychainNotFound = errors.New("la credencial no está en el llavero")
+	// errKeychainUnavailable indica que no hay llavero utilizable: sistema
+	// no soportado o herramienta no instalada.
+	errKeychainUnavailable = errors.New("no hay llavero del sistema disponible")
+)
+
+// keychainPlatforms son las plataformas cuyas credenciales se pueden guardar.
+var keychainPlatforms = []string{"hackerone", "intigriti", "bugcrowd", "yeswehack"}
+
+// keychainName limita los nombres de perfil a caracteres que ningún backend
+// necesita escapar.
+var keychainName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// keychainAccount es la cuenta bajo la que se guarda la credencial de
+// platform para profile (vacío si no se usan perfiles).
+func keychainAccount(platform, profile string) (string, error) {
+	if profile == "" {
+		return platform, nil
+	}
+	if !keychainName.MatchString(profile) {
+		return "", fmt.Errorf("perfil %q no válido para el llavero: usa letras, dígitos, '.', '_' o '-'", profile)
+	}
+	return platform + "/" + profile, nil
+}
+
+// keychainLookup devuelve la credencial guardada de platform para profile.
+// Que no exista o que no haya llavero no es un error: el llavero es opcional.
+func keychainLookup(platform, profile string) (string, bool) {
+	account, err := keychainAccount(platform, profile)
+	if err != nil {
+		log.Printf("aviso: %v", err)
+		return "", false
+	}
+	secret, err := keychainGet(account)
+	switch {
+	case errors.Is(err, errKeychainNotFound), errors.Is(err, errKeychainUnavailable):
+		return "", false
+	case err != nil:
+		log.Printf("aviso: no se pudo leer %s del llavero: %v", account, err)
+		return "", false
+	}
+	return sanitizeKey(secret), secret != ""
+}
+
+// fillFromKeychain completa con el llavero las credenciales que faltan de las
+// plataformas de programs; las de flags, entorno y configuración tienen
+// prioridad.
+func (c *credentialSet) fillFromKeychain(programs []string) {
+	for _, p := range programs {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "hackerone":
+			if len(c.accounts) > 0 || c.key != "" {
+				continue
+			}
+			secret, ok := keychainLookup(p, c.profile)
+			if !ok {
+				continue
+			}
+			accounts, err := parseHackerOneAccounts("", secret, "")
+			if err != nil || len(accounts) != 1 {
+				log.Printf("aviso: la credencial de hackerone del llavero no tiene la forma username:apikey")
+				continue
+			}
+			c.accounts, c.key = accounts, accounts[0].key
+		case platformTokens[p] != nil:
+			if c.tokens[p] != "" {
+				continue
+			}
+			if secret, ok := keychainLookup(p, c.profile); ok {
+				c.tokens[p] = secret
+			}
+		}
+	}
+}
+
+// runAuth implementa "sabb auth login|logout|status": guarda, borra o
+// comprueba las credenciales del llavero que fetch usa cuando no se indican
+// por flags, entorno ni configuración.
+func runAuth(args []string) error {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	profile := fs.String("profile", "", "Perfil al que pertenece la credencial (el mismo que -profile de fetch)")
+	user := fs.String("username", "", "Con login hackerone, el username de HackerOne (si no, se pide)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb auth login <plataforma> | logout <plataforma> | status [-profile perfil]")
+		fmt.Fprintf(fs.Output(), "Plataformas: %s\n", strings.Join(keychainPlatforms, ", "))
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("auth: se espera login, logout o status")
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	// La plataforma puede ir antes o después de los flags.
+	platform := ""
+	if fs.NArg() > 0 {
+		platform = strings.ToLower(fs.Arg(0))
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return err
+		}
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("auth %s: argumento inesperado %q", action, fs.Arg(0))
+	}
+
+	if action == "status" {
+		if platform != "" {
+			fs.Usage()
+			return errors.New("auth status: no admite argumentos")
+		}
+		return authStatus(*profile)
+	}
+	if platform == "" {
+		fs.Usage()
+		return fmt.Errorf("auth %s: se espera una plataforma", action)
+	}
+	if !slices.Contains(keychainPlatforms, platform) {
+		return fmt.Errorf("auth: %s no usa credenciales guardables (%s)", platform, strings.Join(keychainPlatforms, ", "))
+	}
+	account, err := keychainAccount(platform, *profile)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "login":
+		secret, err := readAuthSecret(platform, *user)
+		if err != nil {
+			return err
+		}
+		if err := keychainSet(account, secret); err != nil {
+			return fmt.Errorf("no se pudo guardar %s en el llavero: %w", account, err)
+		}
+		fmt.Fprintf(os.Stderr, "credencial de %s guardada en el llavero\n", account)
+		return nil
+	case "logout":
+		if err := keychainDelete(account); err != nil {
+			return fmt.Errorf("no se pudo borrar %s del llavero: %w", account, err)
+		}
+		fmt.Fprintf(os.Stderr, "credencial de %s borrada del llavero\n", account)
+		return nil
+	}
+	fs.Usage()
+	return fmt.Errorf("auth: acción desconocida %q", action)
+}
+
+// readAuthSecret pide la credencial de platform sin eco; sin terminal la lee
+// de la primera línea de stdin (p. ej. desde un gestor de contraseñas). La de
+// HackerOne se devuelve como username:apikey.
+func readAuthSecret(platform, username string) (string, error) {
+	if platform == "hackerone" && canPrompt() {
+		acc, err := promptHackerOneAccount(username)
+		if err != nil {
+			return "", err
+		}
+		if err := validatePlatformCredentials(platform, acc.credentials()); err != nil {
+			return "", err
+		}
+		return acc.username + ":" + acc.key, nil
+	}
+
+	var secret string
+	if canPrompt() {
+		fmt.Fprintf(os.Stderr, "Token de %s: ", platform)
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("no se pudo leer el token: %w", err)
+		}
+		secret = string(b)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("no se pudo leer la credencial de stdin: %w", err)
+		}
+		secret = line
+	}
+	secret = sanitizeKey(secret)
+
+	creds := Credentials{Token: secret}
+	if platform == "hackerone" {
+		// Desde stdin se acepta username:apikey o solo la apikey con -username.
+		if user, key, ok := strings.Cut(secret, ":"); ok && username == "" {
+			username, secret = user, key
+		}
+		creds = Credentials{Username: sanitizeKey(username), Token: secret}
+	}
+	if err := validatePlatformCredentials(platform, creds); err != nil {
+		return "", err
+	}
+	if platform == "hackerone" {
+		return creds.Username + ":" + creds.Token, nil
+	}
+	return secret, nil
+}
+
+// authStatus indica qué plataformas tienen credencial en el llavero, sin
+// mostrarla. Un llavero bloqueado o inaccesible se informa como tal, no como
+// una credencial que falta.
+func authStatus(profile string) error {
+	failed := false
+	for _, p := range keychainPlatforms {
+		account, err := keychainAccount(p, profile)
+		if err != nil {
+			return err
+		}
+		_, err = keychainGet(account)
+		switch {
+		case err == nil:
+			fmt.Printf("%s: guardada\n", account)
+		case errors.Is(err, errKeychainNotFound):
+			fmt.Printf("%s: no guardada\n", account)
+		case errors.Is(err, errKeychainUnavailable):
+			return err
+		default:
+			fmt.Printf("%s: no se pudo consultar: %v\n", account, err)
+			failed = true
+		}
+	}
+	if failed {
+		return errors.New("auth status: no se pudo consultar el llavero")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// En macOS se usa el Keychain del usuario mediante security(1). Al guardar,
+// la orden se pasa por stdin (security -i) y la contraseña en hexadecimal,
+// para que no aparezca en los argumentos del proceso.
+
+func keychainGet(account string) (string, error) {
+	out, err := securityCommand(nil, "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keychainSet(account, secret string) error {
+	cmd := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -X %s\n",
+		keychainService, account, keychainService+"-"+account, hex.EncodeToString([]byte(secret)))
+	_, err := securityCommand(strings.NewReader(cmd), "-i")
+	return err
+}
+
+func keychainDelete(account string) error {
+	_, err := securityCommand(nil, "delete-generic-password", "-s", keychainService, "-a", account)
+	return err
+}
+
+// securityCommand ejecuta security con args. El código 44
+// (errSecItemNotFound) se traduce a errKeychainNotFound.
+func securityCommand(stdin *strings.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("security", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, errKeychainUnavailable
+	case errors.As(err, &exit) && exit.ExitCode() == 44:
+		return nil, errKeychainNotFound
+	case err != nil:
+		return nil, fmt.Errorf("security: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// En modo interactivo security no devuelve error: lo escribe en stderr.
+	if msg := strings.TrimSpace(stderr.String()); msg != "" && len(args) > 0 && args[0] == "-i" {
+		return nil, fmt.Errorf("security: %s", msg)
+	}
+	return out, nil
+}
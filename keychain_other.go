@@ -0,0 +1,12 @@
+//go:build !unix && !windows
+
+package main
+
+// En el resto de sistemas no hay llavero: las credenciales se indican por
+// flags, entorno o configuración.
+
+func keychainGet(string) (string, error) { return "", errKeychainUnavailable }
+
+func keychainSet(string, string) error { return errKeychainUnavailable }
+
+func keychainDelete(string) error { return errKeychainUnavailable }
@@ -0,0 +1,72 @@
+//go:build unix && !darwin
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// En Linux y BSD se usa Secret Service (GNOME Keyring, KWallet...) mediante
+// secret-tool(1), del paquete libsecret-tools. El secreto se pasa por stdin,
+// nunca en los argumentos del proceso.
+
+func keychainGet(account string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", keychainService, "account", account)
+	if err == nil && len(out) > 0 {
+		return string(out), nil
+	}
+	if err != nil && !errors.Is(err, errKeychainNotFound) {
+		return "", err
+	}
+	// lookup tampoco devuelve nada si el elemento está en una colección
+	// bloqueada; search sí lo lista (sin el secreto), lo que distingue un
+	// llavero bloqueado de una credencial que no existe.
+	found, err := secretTool(nil, "search", "service", keychainService, "account", account)
+	switch {
+	case err != nil && !errors.Is(err, errKeychainNotFound):
+		return "", err
+	case len(bytes.TrimSpace(found)) > 0:
+		return "", errors.New("el llavero está bloqueado: desbloquéalo (p. ej. iniciando sesión en el escritorio) y vuelve a intentarlo")
+	}
+	return "", errKeychainNotFound
+}
+
+func keychainSet(account, secret string) error {
+	_, err := secretTool(strings.NewReader(secret), "store", "--label", keychainService+" "+account,
+		"service", keychainService, "account", account)
+	return err
+}
+
+func keychainDelete(account string) error {
+	if _, err := keychainGet(account); err != nil {
+		return err
+	}
+	_, err := secretTool(nil, "clear", "service", keychainService, "account", account)
+	return err
+}
+
+func secretTool(stdin *strings.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("secret-tool", args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return nil, fmt.Errorf("%w: secret-tool no está instalado (paquete libsecret-tools)", errKeychainUnavailable)
+	case errors.As(err, &exit) && (args[0] == "lookup" || args[0] == "search") && stderr.Len() == 0:
+		// Sin mensaje, una salida distinta de cero es que no hay resultados;
+		// un llavero inaccesible (sin D-Bus, sin servicio) sí escribe en stderr.
+		return nil, errKeychainNotFound
+	case err != nil:
+		return nil, fmt.Errorf("secret-tool: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// En plataformas sin flock el estado no se bloquea; las ejecuciones
+// concurrentes sobre el mismo -state no están protegidas.
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
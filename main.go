@@ -3,18 +3,34 @@ package main
 import (
 	"bufio"
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 	"unicode"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/bountytargets"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/bugcrowd"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/fileprograms"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/hackenproof"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/hackerone"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/immunefi"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/intigriti"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/openbugbounty"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/plugin"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/yeswehack"
 )
 
 // sanitizeKey elimina cualquier carácter de espacio (espacios, tabulaciones,
@@ -28,262 +44,969 @@ func sanitizeKey(k string) string {
 	}, k)
 }
 
-// ProgramFetcher define una interfaz común para las plataformas.
-// Retorna el número de programas procesados y un error en caso de fallo.
+/*****************
+ * Función principal
+ *****************/
+
+// Códigos de salida.
+const (
+	exitGeneric            = 1
+	exitInvalidCredentials = 2
+	exitNetwork            = 3
+)
 
-type ProgramFetcher interface {
-	Fetch(ctx context.Context, apiKey string, out io.Writer) (int, error)
+// exitCode asigna un código de salida según el tipo de error: credenciales
+// (incluidos 401/403 de la API), red o API no disponible, o genérico.
+func exitCode(err error) int {
+	var apiErr *APIError
+	var netErr *url.Error
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		return exitInvalidCredentials
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		return exitInvalidCredentials
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500, errors.As(err, &netErr):
+		return exitNetwork
+	}
+	return exitGeneric
 }
 
-/*************************
- * HackerOne implementation
- *************************/
-
-type hackerOneFetcher struct{}
+// platformsError agrupa los errores de las plataformas que fallaron. Cada uno
+// ya se informó por separado; Unwrap permite clasificarlos con errors.Is/As.
+type platformsError struct{ errs []error }
 
-type hackerOneProgramsPage struct {
-	Data []struct {
-		Attributes struct {
-			Handle         string `json:"handle"`
-			OffersBounties bool   `json:"offers_bounties"`
-		} `json:"attributes"`
-	} `json:"data"`
+func (e *platformsError) Error() string {
+	return fmt.Sprintf("%d plataforma(s)/cuenta(s) con errores", len(e.errs))
 }
 
-type hackerOneScopePage struct {
-	Data []struct {
-		Attributes struct {
-			EligibleForBounty bool   `json:"eligible_for_bounty"`
-			AssetIdentifier   string `json:"asset_identifier"`
-		} `json:"attributes"`
-	} `json:"data"`
+func (e *platformsError) Unwrap() []error { return e.errs }
+
+// stoppedEarly indica si err se debe a que venció el timeout total o a una
+// señal de interrupción (y no al timeout de una solicitud concreta). En ese
+// caso la ejecución no es un fallo: se detiene y se conservan los activos ya
+// escritos.
+func stoppedEarly(ctx context.Context, err error) bool {
+	return err != nil && (ctx.Err() != nil || errors.Is(err, platforms.ErrNoRetryBudget))
 }
 
-func (h hackerOneFetcher) Fetch(ctx context.Context, apiKey string, out io.Writer) (int, error) {
-	// Cliente con timeout más generoso para evitar timeouts prematuros
-	client := &http.Client{Timeout: 30 * time.Second}
+func main() {
+	err := run()
+	if err == nil {
+		return
+	}
+	log.Print(stderrColor.red("ERROR: " + err.Error()))
+	if errors.Is(err, ErrInvalidCredentials) {
+		fmt.Fprintln(os.Stderr, credentialsUsage)
+	}
+	os.Exit(exitCode(err))
+}
 
-	// Extraer username y apiKey del string combinado
-	parts := strings.SplitN(apiKey, ":", 2)
-	if len(parts) != 2 {
-		return 0, fmt.Errorf("formato de credenciales inválido, debe ser username:apikey")
+// Flags de la línea de comandos; run los analiza.
+var (
+	configPath          = configFlag(flag.CommandLine)
+	programFlag         = flag.String("program", "hackerone", "Plataforma(s) separadas por comas: hackerone,intigriti,bugcrowd,yeswehack,hackenproof,immunefi,bountytargets,openbugbounty,file:<ruta> o un plugin sabb-fetcher-<nombre> del PATH (ver -list-platforms)")
+	username            = flag.String("username", "", "HackerOne username (varias cuentas separadas por comas)")
+	apiKey              = flag.String("apikey", "", "API key (varias separadas por comas, en el mismo orden que -username)")
+	credentialsFile     = flag.String("credentials-file", "", "Archivo con una cuenta HackerOne username:apikey por línea")
+	profile             = flag.String("profile", "", "Perfil de credenciales (sección profiles de -config) que se aplica sobre el resto de la configuración")
+	allProfiles         = flag.Bool("all-profiles", false, "Consulta con las credenciales de todos los perfiles de -config y combina los resultados sin duplicados")
+	outputFile          = flag.String("output", "programasguardado.txt", "Destino de salida: archivo, - (stdout), s3://bucket/key o http(s)://url (POST)")
+	splitDir            = flag.String("split-by-program", "", "Directorio donde escribir un archivo <plataforma>/<handle>.txt por programa en vez de -output (con -format txt, jsonl, urls o targets)")
+	format              = flag.String("format", "", "Formato de salida: "+formatNames()+" (por defecto se deduce de la extensión de -output, o txt)")
+	expandWildcards     = flag.Bool("expand-wildcards", false, "Con -format urls, convierte *.example.com en https://example.com")
+	outputMode          = flag.String("output-mode", "0644", "Permisos (octal) del archivo de salida si se crea")
+	timeout             = flag.Duration("timeout", 30*time.Second, "Timeout total de ejecución (0 = sin límite total)")
+	fixtures            = flag.String("fixtures", "", "Directorio de respuestas JSON locales que sustituyen a la API (pruebas de integración)")
+	proxyList           = flag.String("proxy-list", "", "Archivo con un proxy por línea; las solicitudes rotan entre ellos")
+	cacheDir            = flag.String("cache-dir", "", "Directorio de caché HTTP: las respuestas con ETag/Last-Modified se revalidan en vez de descargarse de nuevo")
+	debugDump           = flag.String("debug-dump", "", "Directorio donde volcar cada solicitud y respuesta cruda (Authorization redactada)")
+	metricsAddr         = flag.String("metrics-addr", "", "Dirección (p. ej. :9090) donde exponer métricas Prometheus en /metrics")
+	pageSize            = flag.Int("page-size", hackerone.MaxPageSize, "Programas por página en el listado de HackerOne (1-100)")
+	maxPages            = flag.Int("max-pages", 1000, "Máximo de páginas del listado de programas y del scope de cada uno (0 = sin límite)")
+	includeVDP          = flag.Bool("include-vdp", false, "Procesa también programas sin recompensas (VDP); por defecto solo programas con bounty")
+	scopeCacheSize      = flag.Int("scope-cache-size", 256, "Scopes de programas que se guardan en memoria para no repetir consultas entre cuentas (0 = sin caché)")
+	eligibilityFlag     = flag.String("eligibility", hackerone.EligibilityBounty, "Activos a emitir: bounty (elegibles para recompensa), submission (elegibles para reporte) o any")
+	minBounty           = flag.Float64("min-bounty", 0, "Omite los programas cuya recompensa máxima publicada sea menor que esta cantidad")
+	requireBountyTable  = flag.Bool("require-bounty-table", false, "Omite los programas que no publican tabla de recompensas")
+	since               = flag.Duration("since", 0, "Solo procesa programas actualizados en este intervalo (p. ej. 168h)")
+	storeSpec           = flag.String("store", "", "Guarda programas y activos con su primera y última aparición y su salida del scope en una base de datos (p. ej. sqlite:scopes.db o postgres://user@host/sabb)")
+	incrementalFile     = flag.String("incremental", "", "Archivo donde guardar los scopes de HackerOne entre ejecuciones: solo se vuelven a descargar los de programas actualizados (updated_at) desde entonces")
+	stateFile           = flag.String("state", "", "Archivo de estado con los activos ya vistos; solo se escriben los nuevos")
+	exclusionsFile      = flag.String("emit-exclusions", "", "Archivo donde escribir los activos fuera de scope, prefijados con '!'")
+	strictHandles       = flag.Bool("strict-handles", false, "Aborta si el scope de un programa devuelve 404/403 en vez de omitirlo")
+	strict              = flag.Bool("strict", false, "Depuración: falla si las respuestas traen campos JSON que sabb no modela o datos sobrantes")
+	minAssets           = flag.Int("min-assets", 0, "Falla (código 1) si se escriben menos activos que este mínimo")
+	reportFlag          = flag.Bool("report", false, "Imprime en stderr un recuento de activos por plataforma y tipo")
+	diffFlag            = flag.String("diff", "", "Salida anterior (jsonl o txt) con la que comparar: imprime en stderr los activos nuevos y eliminados por programa")
+	maxRetries          = flag.Int("max-retries", platforms.Retry.MaxRetries, "Reintentos de cada solicitud ante timeouts, 429 y errores 5xx")
+	retryBaseDelay      = flag.Duration("retry-base-delay", platforms.Retry.BaseDelay, "Espera antes del primer reintento; se duplica en cada uno (salvo Retry-After)")
+	rateLimit           = flag.Int("rate-limit", platforms.RateLimits["api.hackerone.com"], "Máximo de solicitudes por minuto a la API de HackerOne, compartido entre cuentas y trabajadores (0 = sin límite)")
+	concurrency         = flag.Int("concurrency", 4, "Scopes de HackerOne que se descargan a la vez (respetando los límites de la API)")
+	parallel            = flag.Int("parallel", 3, "Máximo de plataformas/cuentas ejecutándose a la vez")
+	ordered             = flag.Bool("ordered", false, "Escribe la salida agrupada y en el orden de -program en vez de intercalarla")
+	instructionContains = flag.String("instruction-contains", "", "Solo activos cuya instrucción de scope contiene este texto")
+	instructionExcludes = flag.String("instruction-excludes", "", "Descarta activos cuya instrucción de scope contiene este texto (p. ej. \"no automated\")")
+	collapse            = flag.Bool("collapse", false, "Omite hostnames ya cubiertos por un wildcard del mismo programa (no procesa exclusiones !host)")
+	platformTokens      = map[string]*string{
+		"bugcrowd":  flag.String("bugcrowd-token", "", "Token de la API de Bugcrowd (por defecto -apikey)"),
+		"intigriti": flag.String("intigriti-token", "", "Token de acceso personal de Intigriti (por defecto -apikey)"),
+		"yeswehack": flag.String("yeswehack-token", "", "Token de la API de YesWeHack (por defecto -apikey)"),
 	}
-	username, key := parts[0], parts[1]
-	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + key))
-	processed := 0
+	publicOnly          = flag.Bool("public-only", false, "Usa el directorio público de HackerOne sin credenciales aunque se hayan indicado")
+	verbose             = flag.Bool("verbose", false, "Registra cada programa omitido y el motivo")
+	handle              = flag.String("handle", "", "Procesa solo este programa de HackerOne, sin recorrer el listado")
+	dialTimeout         = flag.Duration("dial-timeout", 10*time.Second, "Timeout para establecer cada conexión TCP")
+	tlsHandshakeTimeout = flag.Duration("tls-handshake-timeout", 10*time.Second, "Timeout del handshake TLS")
+	maxIdleConnsPerHost = flag.Int("max-idle-conns-per-host", 16, "Conexiones inactivas que se mantienen abiertas por host para reutilizarlas")
+	rawDir              = flag.String("raw-dir", "", "Directorio donde guardar el JSON crudo de cada programa y de su scope")
+	maxRedirects        = flag.Int("max-redirects", 3, "Máximo de redirecciones HTTP a seguir en la API (0 = ninguna)")
+	webhook             = flag.String("webhook", "", "URL a la que enviar por POST un resumen JSON al terminar (los fallos solo generan un aviso)")
+	listPlatforms       = flag.Bool("list-platforms", false, "Lista las plataformas soportadas, su estado y la credencial que esperan, y termina")
+	noColor             = flag.Bool("no-color", false, "Desactiva los colores en stderr (también con NO_COLOR)")
+	watch               = flag.Bool("watch", false, "Se queda en ejecución y repite la consulta cada -interval, informando solo de los cambios")
+	notifySpecs         = flagList("notify", "Con -diff o -watch, publica los activos y programas nuevos: slack:<url>, discord:<url>, telegram:<bot_token>:<chat_id> o webhook:<url> con JSON o email:<destinatarios> (los dos últimos incluyen los eliminados; puede repetirse)")
+	smtpServer          = flag.String("smtp-server", "", "Servidor SMTP (host:puerto) para -notify email:")
+	smtpUsername        = flag.String("smtp-username", "", "Usuario SMTP para -notify email: (vacío = sin autenticación)")
+	smtpPassword        = flag.String("smtp-password", "", "Contraseña SMTP para -notify email:")
+	smtpFrom            = flag.String("smtp-from", "", "Remitente de -notify email: (por defecto -smtp-username)")
+	discordMention      = flag.String("discord-mention", "", "Mención (p. ej. @here o <@&rol>) que -notify discord: añade si hay wildcards nuevos")
+	removedOutput       = flag.String("removed-output", "", "Con -diff o -watch, añade a este archivo los activos que salieron del scope (formato según la extensión)")
+	feedFile            = flag.String("feed", "", "Con -diff o -watch, añade los cambios de scope a este feed Atom (una entrada por programa)")
+	fsync               = flag.Bool("fsync", false, "Sincroniza el archivo de salida con el disco después de cada programa, no solo al terminar")
+	checkpointPath      = flag.String("checkpoint", "", "Archivo donde registrar lo ya escrito si la ejecución no se completa (timeout, Ctrl-C o errores)")
+	resume              = flag.Bool("resume", false, "Continúa la ejecución registrada en -checkpoint, omitiendo lo que ya se escribió")
+	interval            = flag.Duration("interval", 6*time.Hour, "Con -watch, tiempo entre el inicio de una consulta y el de la siguiente")
+)
 
-	for page := 1; ; page++ {
-		select {
-		case <-ctx.Done():
-			return processed, ctx.Err()
-		default:
-		}
+func init() {
+	flag.StringVar(programFlag, "platform", *programFlag, "Alias de -program")
+	flag.Usage = usage
+}
 
-		url := fmt.Sprintf("https://api.hackerone.com/v1/hackers/programs?page[number]=%d&page[size]=100", page)
-		body, err := doRequestWithRetry(ctx, client, url, auth)
-		if err != nil {
-			return processed, fmt.Errorf("programs page request failed: %w", err)
-		}
+// subcommand es una orden de "sabb <orden>"; summary es su línea en la ayuda.
+type subcommand struct {
+	run     func(args []string) error
+	summary string
+}
 
-		var pg hackerOneProgramsPage
-		if err := safeUnmarshal(body, &pg); err != nil {
-			return processed, err
-		}
+// subcommands son las órdenes de la CLI. Sin orden (solo flags) se ejecuta
+// fetch, como en las versiones anteriores.
+var subcommands = map[string]subcommand{
+	"fetch":   {runFetch, "Descarga el scope de las plataformas (la orden por defecto)"},
+	"diff":    {runDiff, "Compara dos salidas jsonl o txt y muestra los activos nuevos y eliminados"},
+	"export":  {runExport, "Escribe el scope guardado en -store en cualquier formato de salida"},
+	"query":   {runQuery, "Busca activos en -store por plataforma, programa, tipo o texto"},
+	"serve":   {runServe, "Sirve el scope guardado en -store por HTTP"},
+	"history": {runHistory, "Muestra cuándo entró y salió del scope un activo o programa de -store"},
+	"new":     {runNew, "Lista los programas y activos de -store vistos por primera vez en un intervalo"},
+	"auth":    {runAuth, "Guarda en el llavero del sistema las credenciales de una plataforma (login, logout, status)"},
+}
 
-		if len(pg.Data) == 0 {
-			break // no more pages
-		}
+// usage es la ayuda general: las órdenes y los flags de fetch.
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintln(w, "Uso: sabb [orden] [flags]")
+	fmt.Fprintln(w, "\nÓrdenes (sabb <orden> -h muestra sus flags):")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, subcommands[name].summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nFlags de fetch:")
+	flag.PrintDefaults()
+}
 
-		for _, d := range pg.Data {
-			if !d.Attributes.OffersBounties {
-				continue
-			}
-			handle := d.Attributes.Handle
-			fmt.Printf("Procesando: %s\n", handle)
+// run ejecuta la orden indicada en la línea de comandos y devuelve el error
+// que determina el código de salida.
+func run() error {
+	args := os.Args[1:]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runFetch(args)
+	}
+	cmd, ok := subcommands[args[0]]
+	if !ok {
+		return fmt.Errorf("orden desconocida %q (sabb -h lista las órdenes)", args[0])
+	}
+	// -h ya imprimió la ayuda de la orden.
+	if err := cmd.run(args[1:]); !errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return nil
+}
 
-			assets, err := h.fetchEligibleAssets(ctx, client, auth, handle)
-			if err != nil {
-				// devolvemos error: usuario pidió que solo salga el error
-				return processed, fmt.Errorf("handle %s failed: %w", handle, err)
-			}
-			for _, asset := range assets {
-				fmt.Fprintln(out, asset)
-			}
-			processed++
-		}
+// runFetch implementa "sabb fetch [flags]": consulta las plataformas y
+// escribe su scope. Todos los caminos de error pasan por los defer, de modo
+// que el estado, los archivos abiertos y la salida se cierran correctamente.
+func runFetch(args []string) error {
+	flag.CommandLine.Parse(args)
+	if flag.NArg() > 0 {
+		return fmt.Errorf("fetch: argumento inesperado %q", flag.Arg(0))
+	}
+	if *profile != "" && *allProfiles {
+		return errors.New("-profile y -all-profiles son incompatibles")
+	}
+	if err := applyConfig(flag.CommandLine, *configPath, ""); err != nil {
+		return err
+	}
+	if err := applyCredentialEnv(flag.CommandLine); err != nil {
+		return err
 	}
 
-	return processed, nil
-}
+	stderrColor = newStderrColorizer(*noColor)
 
-// doRequestWithRetry intenta la solicitud hasta 3 veces con un delay exponencial
-func doRequestWithRetry(ctx context.Context, client *http.Client, url, auth string) ([]byte, error) {
-	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
-		if attempt > 0 {
-			// Espera exponencial: 1s, 2s, 4s
-			delay := time.Duration(1<<uint(attempt)) * time.Second
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(delay):
-			}
-		}
+	if *listPlatforms {
+		return printPlatforms(os.Stdout)
+	}
 
-		body, err := doRequest(ctx, client, url, auth)
-		if err == nil {
-			return body, nil
-		}
-		lastErr = err
+	credentials, err := fetchCredentials()
+	if err != nil {
+		return err
+	}
 
-		// Si el error no es por timeout, no reintentamos
-		if !strings.Contains(err.Error(), "deadline exceeded") {
-			return nil, err
+	// -handle "" se distingue de no indicarlo: sería recorrer todo el listado
+	// cuando se pedía un único programa.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "handle" && strings.TrimSpace(*handle) == "" {
+			err = errors.New("-handle no puede estar vacío")
 		}
+	})
+	if err != nil {
+		return err
 	}
-	return nil, fmt.Errorf("después de 3 intentos: %w", lastErr)
-}
 
-func (h hackerOneFetcher) fetchEligibleAssets(ctx context.Context, client *http.Client, auth, handle string) ([]string, error) {
-	url := fmt.Sprintf("https://api.hackerone.com/v1/hackers/programs/%s/structured_scopes", handle)
-	body, err := doRequestWithRetry(ctx, client, url, auth)
+	eligibility, err := hackerone.ParseEligibility(*eligibilityFlag)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var pg hackerOneScopePage
-	if err := safeUnmarshal(body, &pg); err != nil {
-		return nil, err
+	mode, err := parseFileMode(*outputMode)
+	if err != nil {
+		return err
 	}
 
-	var assets []string
-	for _, d := range pg.Data {
-		if d.Attributes.EligibleForBounty {
-			assets = append(assets, d.Attributes.AssetIdentifier)
+	var notifiers []notifier
+	for _, spec := range *notifySpecs {
+		n, err := parseNotifier(spec)
+		if err != nil {
+			return err
 		}
+		notifiers = append(notifiers, n)
 	}
-	return assets, nil
-}
-
-// doRequest centraliza la lógica HTTP con manejo de errores, timeout y códigos de estado.
-func doRequest(ctx context.Context, client *http.Client, url, auth string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+	if len(notifiers) > 0 && *diffFlag == "" && !*watch {
+		return errors.New("-notify requiere -diff o -watch")
+	}
+	if *removedOutput != "" && *diffFlag == "" && !*watch {
+		return errors.New("-removed-output requiere -diff o -watch")
+	}
+	if *resume && (*checkpointPath == "" || *watch) {
+		return errors.New("-resume requiere -checkpoint y no admite -watch")
+	}
+	// Reanudar añade a la salida lo que faltaba, y en un formato de documento
+	// completo eso deja un segundo documento detrás del parcial.
+	if outFormat := *format; *resume && *splitDir == "" {
+		if outFormat == "" {
+			outFormat = formatFromOutput(*outputFile)
+		}
+		if !formatAppends(outFormat) {
+			return fmt.Errorf("-resume no admite -format %s: usa un formato de una línea por activo (txt, jsonl, urls o targets)", outFormat)
+		}
+	}
+	if *feedFile != "" && *diffFlag == "" && !*watch {
+		return errors.New("-feed requiere -diff o -watch")
+	}
+	// Cada programa se añade a su archivo conforme llega: solo caben formatos
+	// de una línea por activo.
+	if *splitDir != "" && *format != "" && !formatAppends(*format) {
+		return fmt.Errorf("-split-by-program no admite -format %s: usa un formato de una línea por activo (txt, jsonl, urls o targets)", *format)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic "+auth)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if *maxRetries < 0 {
+		return errors.New("-max-retries no puede ser negativo")
 	}
-	defer resp.Body.Close()
+	platforms.Retry.MaxRetries = *maxRetries
+	platforms.Retry.BaseDelay = *retryBaseDelay
+	platforms.RateLimits["api.hackerone.com"] = *rateLimit
 
-	if resp.StatusCode >= 500 {
-		return nil, fmt.Errorf("API unavailable: %s", resp.Status)
+	if *metricsAddr != "" {
+		serveMetrics(*metricsAddr)
 	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API returned error %s", resp.Status)
+
+	// Ctrl-C/SIGTERM cancelan la ejecución de forma ordenada. Un -timeout cero
+	// o negativo desactiva el límite total; los timeouts por solicitud siguen
+	// aplicándose.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if !*watch {
+		_, err := fetchOnce(ctx, credentials, eligibility, mode, notifiers, nil)
+		return err
 	}
+	return watchLoop(ctx, *interval, func(ctx context.Context, previous *scopeDiff) (*scopeDiff, error) {
+		return fetchOnce(ctx, credentials, eligibility, mode, notifiers, previous)
+	})
+}
 
-	body, err := io.ReadAll(resp.Body)
+// fetchCredentials reúne las credenciales de la ejecución: las de cada perfil
+// con -all-profiles o, si no, las de los flags. Lo que falte se busca en el
+// llavero del sistema (sabb auth login). Sin credenciales de ningún origen,
+// se piden por terminal si es posible; en contextos no interactivos se falla
+// de inmediato.
+func fetchCredentials() ([]credentialSet, error) {
+	programs := strings.Split(*programFlag, ",")
+	if *allProfiles {
+		if *username != "" || *apiKey != "" || *credentialsFile != "" {
+			log.Printf("aviso: con -all-profiles se usan solo las credenciales de los perfiles")
+		}
+		sets, err := loadProfileCredentials(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		if *fixtures == "" {
+			for i := range sets {
+				sets[i].fillFromKeychain(programs)
+			}
+		}
+		return sets, nil
+	}
+	if *fixtures != "" && *credentialsFile == "" && *apiKey == "" {
+		// Con fixtures no se contacta la API: basta con credenciales ficticias.
+		*username, *apiKey = "fixtures", "fixtures"
+	}
+	tokens := make(map[string]string, len(platformTokens))
+	for p, t := range platformTokens {
+		tokens[p] = *t
+	}
+	set, err := newCredentialSet(*profile, *username, *apiKey, *credentialsFile, tokens)
 	if err != nil {
 		return nil, err
 	}
-	return body, nil
-}
+	if *fixtures == "" && !*publicOnly {
+		set.fillFromKeychain(programs)
+	}
 
-// safeUnmarshal incluye recuperación de panic por JSON inválido.
-func safeUnmarshal(data []byte, v interface{}) error {
-	defer func() {
-		if r := recover(); r != nil {
-			err, ok := r.(error)
-			if !ok {
-				err = errors.New("panic")
-			}
-			log.Printf("panic recuperado: %v", err)
+	if !*publicOnly && set.needsHackerOnePrompt(programs) && canPrompt() {
+		acc, err := promptHackerOneAccount(*username)
+		if err != nil {
+			return nil, err
+		}
+		// Una apikey vacía equivale a no tener credenciales: HackerOne pasa
+		// al directorio público. Solo se guarda como cuenta, no en key, para
+		// que no acabe como token de otras plataformas.
+		if acc.key != "" {
+			set.accounts = []hackerOneAccount{acc}
 		}
-	}()
-	if err := json.Unmarshal(data, v); err != nil {
-		return fmt.Errorf("JSON decode failed: %w", err)
 	}
-	return nil
+	return []credentialSet{set}, nil
 }
 
-/**********************************
- * Placeholders para otras plataformas
- **********************************/
-
-type notImplementedFetcher struct{ name string }
-
-func (n notImplementedFetcher) Fetch(context.Context, string, io.Writer) (int, error) {
-	return 0, fmt.Errorf("fetcher para %s aún no implementado", n.name)
-}
+// fetchOnce consulta las plataformas una vez y entrega la salida, el estado y
+// los informes. Con -watch se llama en cada iteración; -timeout se aplica a
+// cada una por separado.
+func fetchOnce(ctx context.Context, credentials []credentialSet, eligibility string, mode os.FileMode, notifiers []notifier, previous *scopeDiff) (*scopeDiff, error) {
+	accounts, cleanKey := mergeCredentialAccounts(credentials)
+	var err error
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
-/*****************
- * Función principal
- *****************/
+	// Con -state solo se escriben los activos nunca vistos en ejecuciones
+	// anteriores; con varias cuentas se deduplican los scopes compartidos.
+	var state *assetState
+	if *stateFile != "" {
+		state, err = openAssetState(*stateFile)
+		if err != nil {
+			return nil, err
+		}
+		defer state.Close()
+	}
 
-func main() {
-	programFlag := flag.String("program", "hackerone", "Plataforma(s) separadas por comas: hackerone,intigriti,bugcrowd")
-	username := flag.String("username", "", "HackerOne username")
-	apiKey := flag.String("apikey", "", "API key")
-	outputFile := flag.String("output", "programasguardado.txt", "Archivo de salida")
-	timeout := flag.Duration("timeout", 30*time.Second, "Timeout total de ejecución")
-	flag.Parse()
+	// La salida anterior se lee antes de abrir -output, que puede ser el
+	// mismo archivo.
+	var diff *scopeDiff
+	diffPath := *diffFlag
+	switch {
+	case previous != nil:
+		diff, diffPath = previous, "la consulta anterior"
+	case diffPath != "":
+		if diff, err = loadScopeDiff(diffPath); err != nil {
+			return nil, err
+		}
+	case *watch:
+		// Primera consulta de -watch sin -diff: no hay con qué comparar, pero
+		// sirve de referencia para la siguiente.
+		diff = newScopeDiff()
+		diff.baseline = true
+	}
 
-	if *apiKey == "" {
-		log.Fatal("apikey es obligatorio")
+	var cp *checkpoint
+	switch {
+	case *resume:
+		if cp, err = loadCheckpoint(*checkpointPath); err != nil {
+			return nil, err
+		}
+	case *checkpointPath != "":
+		cp = newCheckpoint()
 	}
-	if *username == "" {
-		log.Fatal("username es obligatorio para HackerOne")
+	resumed := cp != nil && cp.resumed
+
+	var snapshot *platforms.ScopeSnapshot
+	if *incrementalFile != "" {
+		if snapshot, err = platforms.LoadScopeSnapshot(*incrementalFile); err != nil {
+			return nil, fmt.Errorf("-incremental: %w", err)
+		}
 	}
 
-	cleanKey := sanitizeKey(*apiKey)
-	cleanUsername := sanitizeKey(*username)
+	var store *assetStore
+	if *storeSpec != "" {
+		store, err = openAssetStore(*storeSpec)
+		if err != nil {
+			return nil, err
+		}
+		// Si no se llegó a cerrar explícitamente (error antes de terminar),
+		// se conserva lo ya guardado.
+		defer func() {
+			if store != nil {
+				store.Close()
+			}
+		}()
+		// Los programas se guardan aunque no tengan activos en scope: si no,
+		// se darían por eliminados en cada ejecución.
+		programHook := platforms.Hooks.Program
+		platforms.Hooks.Program = func(p Program) {
+			store.observeProgram(p)
+			if programHook != nil {
+				programHook(p)
+			}
+		}
+		defer func() { platforms.Hooks.Program = programHook }()
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	var exclusions AssetWriter
+	if *exclusionsFile != "" {
+		ef, err := openOutputFile(*exclusionsFile, mode, true)
+		if err != nil {
+			return nil, err
+		}
+		defer ef.Close()
+		ew := bufio.NewWriter(ef)
+		defer func() {
+			if err := ew.Flush(); err != nil {
+				log.Printf("no se pudo escribir %s: %v", *exclusionsFile, err)
+			}
+		}()
+		exclusions = exclusionLines(&syncWriter{w: ew})
+	}
+	// Los formatos que incluyen el out_of_scope lo reciben por el mismo
+	// camino que -emit-exclusions.
+	outFormat := *format
+	if outFormat == "" {
+		outFormat = formatFromOutput(*outputFile)
+	}
+	var excludedScope *exclusionList
+	if formatNeedsExclusions(outFormat) && *splitDir == "" {
+		excludedScope = &exclusionList{}
+		if exclusions != nil {
+			exclusions = teeAssets(exclusions, excludedScope)
+		} else {
+			exclusions = excludedScope
+		}
+	}
 
-	f, err := os.OpenFile(*outputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		log.Fatalf("no se pudo abrir %s: %v", *outputFile, err)
+	transport := newTransport(transportOptions{
+		dialTimeout:         *dialTimeout,
+		tlsHandshakeTimeout: *tlsHandshakeTimeout,
+		maxIdleConnsPerHost: *maxIdleConnsPerHost,
+	})
+	client := &http.Client{
+		Timeout:       30 * time.Second,
+		Transport:     transport,
+		CheckRedirect: redirectPolicy(*maxRedirects),
+	}
+	if *proxyList != "" {
+		rotator, err := loadProxyList(*proxyList)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = newProxyTransport(rotator, transport)
+	}
+	if *fixtures != "" {
+		client.Transport = fixtureTransport{dir: *fixtures}
+	}
+	if *debugDump != "" {
+		dump, err := newDumpTransport(*debugDump, client.Transport)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = dump
+	}
+	// La caché envuelve al volcado para que este muestre el tráfico real
+	// (las revalidaciones y sus 304).
+	if *cacheDir != "" {
+		cache, err := newCacheTransport(*cacheDir, client.Transport)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = cache
 	}
-	defer f.Close()
 
-	writer := bufio.NewWriter(f)
-	defer writer.Flush()
+	var raw *hackerone.RawStore
+	if *rawDir != "" {
+		if raw, err = hackerone.NewRawStore(*rawDir); err != nil {
+			return nil, err
+		}
+	}
 
 	fetchers := map[string]ProgramFetcher{
-		"hackerone": hackerOneFetcher{},
-		"intigriti": notImplementedFetcher{"Intigriti"},
-		"bugcrowd":  notImplementedFetcher{"Bugcrowd"},
+		"hackerone": hackerone.Fetcher{
+			Client:              client,
+			Collapse:            *collapse,
+			IncludeVDP:          *includeVDP,
+			Exclusions:          exclusions,
+			Strict:              *strict,
+			StrictHandles:       *strictHandles,
+			Since:               *since,
+			InstructionContains: *instructionContains,
+			InstructionExcludes: *instructionExcludes,
+			PageSize:            hackerone.ClampPageSize(*pageSize),
+			MaxPages:            *maxPages,
+			MinBounty:           *minBounty,
+			RequireBountyTable:  *requireBountyTable,
+			Eligibility:         eligibility,
+			Scopes:              platforms.NewScopeCache(*scopeCacheSize),
+			Snapshot:            snapshot,
+			Raw:                 raw,
+			Handle:              strings.TrimSpace(*handle),
+			Verbose:             *verbose,
+			Concurrency:         *concurrency,
+			Skip:                cp.skipFunc("hackerone"),
+		},
+		"yeswehack": yeswehack.Fetcher{
+			Client:     client,
+			Exclusions: exclusions,
+			IncludeVDP: *includeVDP,
+			Strict:     *strict,
+			MaxPages:   *maxPages,
+		},
+		"hackenproof": hackenproof.Fetcher{
+			Client:     client,
+			Exclusions: exclusions,
+			IncludeVDP: *includeVDP,
+			Strict:     *strict,
+			MaxPages:   *maxPages,
+		},
+		"bountytargets": bountytargets.Fetcher{
+			Client:      client,
+			Exclusions:  exclusions,
+			IncludeVDP:  *includeVDP,
+			Eligibility: eligibility,
+		},
+		"openbugbounty": openbugbounty.Fetcher{
+			Client:   client,
+			MaxPages: *maxPages,
+		},
+		"immunefi": immunefi.Fetcher{
+			Client: client,
+			Strict: *strict,
+		},
+		"intigriti": intigriti.Fetcher{
+			Client:     client,
+			IncludeVDP: *includeVDP,
+			Exclusions: exclusions,
+			Strict:     *strict,
+			MaxPages:   *maxPages,
+		},
+		"bugcrowd": bugcrowd.Fetcher{
+			Client:     client,
+			Exclusions: exclusions,
+			IncludeVDP: *includeVDP,
+			Strict:     *strict,
+			MaxPages:   *maxPages,
+		},
 	}
 
-	total := 0
-
+	// Sin ninguna credencial (o con -public-only) HackerOne usa el directorio
+	// público en lugar de fallar.
+	publicHackerOne := *publicOnly || (len(accounts) == 0 && cleanKey == "")
+	var jobs []platformJob
 	for _, p := range strings.Split(*programFlag, ",") {
-		p = strings.ToLower(strings.TrimSpace(p))
+		p = strings.TrimSpace(p)
+		// La ruta de file: conserva las mayúsculas (no puede contener comas).
+		if len(p) > len(filePlatformPrefix) && strings.EqualFold(p[:len(filePlatformPrefix)], filePlatformPrefix) {
+			path := p[len(filePlatformPrefix):]
+			jobs = append(jobs, platformJob{platform: "file", label: "file (" + filepath.Base(path) + ")", fetcher: fileprograms.Fetcher{
+				Path:       path,
+				Exclusions: exclusions,
+				IncludeVDP: *includeVDP,
+			}})
+			continue
+		}
+		p = strings.ToLower(p)
 		fetcher, ok := fetchers[p]
 		if !ok {
-			log.Printf("programa desconocido: %s", p)
+			// Las plataformas que sabb no conoce se buscan como plugin
+			// (sabb-fetcher-<nombre> en el PATH).
+			path, found := plugin.Lookup(p)
+			if !found {
+				log.Printf("programa desconocido: %s", p)
+				continue
+			}
+			jobs = append(jobs, platformJob{platform: p, label: p + " (plugin)", fetcher: plugin.Fetcher{
+				Name:       p,
+				Path:       path,
+				Exclusions: exclusions,
+				IncludeVDP: *includeVDP,
+				MaxPages:   *maxPages,
+			}})
 			continue
 		}
-		var credentials string
 		if p == "hackerone" {
-			credentials = cleanUsername + ":" + cleanKey
+			if publicHackerOne {
+				if !*publicOnly {
+					log.Printf("sin credenciales de HackerOne: se usa el directorio público de programas")
+				}
+				h := fetcher.(hackerone.Fetcher)
+				h.Public = true
+				jobs = append(jobs, platformJob{platform: p, label: p + " (público)", fetcher: h})
+				continue
+			}
+			// Sin cuentas se valida el token suelto para que el error diga qué falta.
+			accs := accounts
+			if len(accs) == 0 {
+				accs = []hackerOneAccount{{key: cleanKey}}
+			}
+			for _, acc := range accs {
+				if err := validatePlatformCredentials(p, acc.credentials()); err != nil {
+					return nil, err
+				}
+			}
+			for _, acc := range accounts {
+				label := p
+				if len(accounts) > 1 {
+					label = p + " (" + acc.username + ")"
+				}
+				jobs = append(jobs, platformJob{platform: p, label: label, fetcher: fetcher, creds: acc.credentials()})
+			}
+			continue
+		}
+		// Las plataformas públicas no reciben ningún token, para no enviar a
+		// un tercero la clave de otra plataforma.
+		if isPublicPlatform(p) {
+			if err := validatePlatformCredentials(p, Credentials{}); err != nil {
+				return nil, err
+			}
+			jobs = append(jobs, platformJob{platform: p, label: p, fetcher: fetcher})
+			continue
+		}
+		// Cada plataforma puede tener su propio token; sin él se usa -apikey.
+		// Con -all-profiles hay un trabajo por perfil con token y se omiten
+		// los perfiles sin él.
+		before := len(jobs)
+		for _, set := range credentials {
+			creds := Credentials{Token: set.token(p)}
+			if creds.Token == "" && len(credentials) > 1 {
+				continue
+			}
+			if err := validatePlatformCredentials(p, creds); err != nil {
+				return nil, err
+			}
+			label := p
+			if len(credentials) > 1 {
+				label = p + " (" + set.profile + ")"
+			}
+			jobs = append(jobs, platformJob{platform: p, label: label, fetcher: fetcher, creds: creds})
+		}
+		if len(jobs) == before {
+			if err := validatePlatformCredentials(p, Credentials{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resumed {
+		pending := jobs[:0]
+		for _, j := range jobs {
+			if cp.platformDone(j.label) {
+				log.Printf("se omite %s: ya terminó en la ejecución que se reanuda", j.label)
+				continue
+			}
+			pending = append(pending, j)
+		}
+		jobs = pending
+	}
+
+	// La salida se abre en último lugar: a partir de aquí solo puede fallar su
+	// entrega. Con -split-by-program cada programa va a su propio archivo y no
+	// se abre la salida combinada.
+	var (
+		dst     outputDestination
+		writer  *bufio.Writer
+		encoder assetEncoder
+	)
+	if *splitDir != "" {
+		// La extensión de -output no cuenta: con -split-by-program no se usa.
+		encoder, err = newProgramSplitter(*splitDir, *format, mode, !*watch, *fsync, encoderOptions{
+			expandWildcards: *expandWildcards,
+		})
+	} else {
+		// Con -watch, o con -diff sobre el propio -output, la salida se
+		// reescribe: añadirla repetiría el scope completo en cada consulta.
+		appendOutput := formatAppends(outFormat) && !*watch && !sameFile(*diffFlag, *outputFile)
+		dst, err = openOutput(*outputFile, mode, appendOutput, formatContentType(outFormat))
+		if err == nil {
+			writer = bufio.NewWriter(dst)
+			encoder, err = newAssetEncoder(outFormat, writer, encoderOptions{
+				expandWildcards: *expandWildcards,
+				exclusions:      excludedScope,
+			})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Cada programa que empieza deja escrito en la salida el anterior.
+	if writer != nil {
+		flusher := newProgramFlusher(encoder, writer, dst, *fsync)
+		encoder = flusher
+		processing := platforms.Hooks.Processing
+		platforms.Hooks.Processing = func(handle string) {
+			flusher.flush()
+			processing(handle)
+		}
+		defer func() { platforms.Hooks.Processing = processing }()
+	}
+
+	// counter ve los activos que realmente se escriben, ya deduplicados.
+	counter := &assetCounter{next: encoder}
+	var report *assetReport
+	var out AssetWriter = counter
+	if *reportFlag {
+		report = newAssetReport()
+		out = report.wrap(out)
+	}
+	switch {
+	case state != nil:
+		out = dedupAssets(out, state.seen)
+	case len(accounts) > 1 || len(credentials) > 1:
+		out = dedupAssets(out, nil)
+	}
+	// El almacén ve también los activos ya vistos para actualizar last_seen.
+	if store != nil {
+		out = store.wrap(out)
+	}
+	if diff != nil {
+		out = diff.wrap(out)
+	}
+	if cp != nil {
+		out = cp.wrap(out)
+	}
+	out = &syncAssetWriter{w: out}
+
+	// Las plataformas (y cuentas) son independientes y se ejecutan en paralelo;
+	// un fallo en una no detiene a las demás.
+	started := time.Now()
+	total := 0
+	stopped := false
+	var failures []error
+	failed := make(map[string]bool)
+	var finished []string
+	var summary runSummary
+	stats := newStats()
+	for _, r := range runPlatforms(ctx, jobs, out, *parallel, *ordered, stats) {
+		total += r.processed
+		counts := stats.platform(r.job.label)
+		ps := platformSummary{
+			Name:     r.job.label,
+			Programs: r.processed,
+			Assets:   counts.assets.Load(),
+			Requests: counts.requests.Load(),
+		}
+		if r.err != nil && !stoppedEarly(ctx, r.err) {
+			ps.Error = r.err.Error()
+		}
+		summary.Platforms = append(summary.Platforms, ps)
+		switch {
+		case r.err == nil:
+			finished = append(finished, r.job.label)
+			fmt.Fprintln(os.Stderr, stderrColor.green(fmt.Sprintf("%s: %d programas procesados", r.job.label, r.processed)))
+		case stoppedEarly(ctx, r.err):
+			stopped = true
+			fmt.Fprintln(os.Stderr, stderrColor.green(fmt.Sprintf("%s: %d programas procesados (detenido)", r.job.label, r.processed)))
+		default:
+			failures = append(failures, r.err)
+			failed[r.job.platform] = true
+			log.Print(stderrColor.red(fmt.Sprintf("ERROR (%s): %v", r.job.label, r.err)))
+		}
+	}
+	if stopped {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			log.Printf("timeout total alcanzado: se detiene la ejecución y se conservan los activos ya recogidos")
 		} else {
-			credentials = cleanKey
+			log.Printf("ejecución interrumpida: se conservan los activos ya recogidos")
+		}
+	}
+
+	// La salida se entrega también cuando la ejecución terminó antes de tiempo
+	// o alguna plataforma falló, para no perder los activos ya recogidos.
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	if writer != nil {
+		if err := writer.Flush(); err != nil {
+			return nil, fmt.Errorf("no se pudo escribir %s: %w", *outputFile, err)
+		}
+		if err := dst.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	// El punto de control solo se conserva mientras quede algo pendiente.
+	if cp != nil {
+		if cp.skipped > 0 {
+			log.Printf("se omitieron %d activos ya escritos en la ejecución que se reanuda", cp.skipped)
+		}
+		if !stopped && len(failures) == 0 {
+			if err := os.Remove(*checkpointPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("no se pudo borrar %s: %v", *checkpointPath, err)
+			}
+		} else if err := cp.save(*checkpointPath, finished); err != nil {
+			log.Printf("no se pudo guardar el punto de control: %v", err)
+		} else {
+			log.Printf("punto de control guardado en %s: continúa con -resume", *checkpointPath)
+		}
+	}
+
+	// Si alguna plataforma falló no se actualiza el estado, para que sus
+	// activos vuelvan a considerarse nuevos en la siguiente ejecución.
+	if state != nil && len(failures) == 0 {
+		if err := state.save(); err != nil {
+			log.Printf("no se pudo guardar el estado: %v", err)
 		}
-		cnt, err := fetcher.Fetch(ctx, credentials, writer)
+	}
+
+	// Los scopes que no se usaron solo se descartan si se recorrió el
+	// listado completo de HackerOne.
+	if snapshot != nil {
+		if n := snapshot.Reused(); n > 0 {
+			log.Printf("%d scopes de HackerOne sin cambios reutilizados de %s", n, *incrementalFile)
+		}
+		prune := !stopped && !resumed && !failed["hackerone"] && *handle == "" && *since == 0
+		if err := snapshot.Save(prune); err != nil {
+			log.Printf("no se pudo guardar -incremental: %v", err)
+		}
+	}
+
+	if store != nil {
+		// Lo que no se vio en una plataforma incompleta (o en una ejecución
+		// reanudada) no salió del scope.
+		var err error
+		if !stopped && !resumed {
+			// Una paginación cortada en -max-pages deja la plataforma tan
+			// incompleta como un fallo.
+			truncated := make(map[string]bool)
+			for _, j := range jobs {
+				if stats.platform(j.label).truncated.Load() {
+					truncated[j.platform] = true
+				}
+			}
+			completed := make(map[string]removalScope)
+			for _, j := range jobs {
+				if failed[j.platform] || truncated[j.platform] {
+					continue
+				}
+				scope := removalScope{programs: true, assets: true}
+				// Los filtros de HackerOne dejan fuera programas y activos que
+				// no salieron del scope; el directorio público ve otro listado.
+				if j.platform == "hackerone" {
+					scope.programs = *handle == "" && *since == 0 && *minBounty == 0 && !*requireBountyTable && !publicHackerOne
+					scope.assets = !*collapse && *instructionContains == "" && *instructionExcludes == ""
+				}
+				completed[j.platform] = scope
+			}
+			err = store.markRemoved(completed)
+		}
+		if cerr := store.Close(); err == nil {
+			err = cerr
+		}
+		store = nil
 		if err != nil {
-			// Imprime sólo el error y termina — petición del usuario
-			log.Fatalf("ERROR: %v", err)
+			return nil, fmt.Errorf("no se pudo guardar en -store: %w", err)
+		}
+	}
+
+	if report != nil {
+		report.print(os.Stderr)
+	}
+	complete := !stopped && len(failures) == 0 && !resumed
+	var changes []programChange
+	if diff != nil && !diff.baseline {
+		// Con la ejecución incompleta faltan activos que no se eliminaron.
+		if !complete {
+			log.Printf("aviso: -diff no informa de activos eliminados porque la ejecución no se completó")
 		}
-		total += cnt
+		ran := make(map[string]bool)
+		for _, j := range jobs {
+			ran[j.platform] = true
+		}
+		changes = diff.changes(complete, ran)
+		printDiff(os.Stderr, diffPath, changes)
+		sendNotifications(notifiers, changes)
+		if *removedOutput != "" && complete {
+			if err := writeRemoved(*removedOutput, changes, mode); err != nil {
+				log.Printf("no se pudo escribir -removed-output: %v", err)
+			}
+		}
+		if *feedFile != "" {
+			if err := writeFeed(*feedFile, changes, time.Now(), mode); err != nil {
+				log.Printf("no se pudo escribir -feed: %v", err)
+			}
+		}
+	}
+
+	stats.print(os.Stderr)
+	fmt.Fprintln(os.Stderr, stderrColor.green(fmt.Sprintf("Total de programas procesados: %d", total)))
+	var runErr error
+	switch {
+	case len(failures) > 0:
+		runErr = &platformsError{errs: failures}
+	case counter.n < *minAssets:
+		// Muy pocos activos suelen indicar un cambio en la API o un problema
+		// de autenticación que no se manifestó como error.
+		runErr = fmt.Errorf("se escribieron %d activos, menos que el mínimo esperado (-min-assets %d)", counter.n, *minAssets)
 	}
 
-	fmt.Printf("Total de programas procesados: %d\n", total)
+	if *webhook != "" {
+		summary.TotalPrograms = total
+		summary.TotalAssets = counter.n
+		switch {
+		case diff != nil && !diff.baseline:
+			added, removed := 0, 0
+			for _, c := range changes {
+				added += len(c.added)
+				removed += len(c.removed)
+			}
+			summary.NewAssets = &added
+			if complete {
+				summary.RemovedAssets = &removed
+			}
+		case state != nil:
+			summary.NewAssets = &counter.n
+		}
+		if runErr != nil {
+			for _, err := range failures {
+				summary.Errors = append(summary.Errors, err.Error())
+			}
+			if len(failures) == 0 {
+				summary.Errors = append(summary.Errors, runErr.Error())
+			}
+		}
+		summary.Stopped = stopped
+		summary.DurationSeconds = time.Since(started).Seconds()
+		sendWebhook(*webhook, summary)
+	}
+	return diff.next(complete), runErr
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+/*****************
+ * Métricas Prometheus
+ *****************/
+
+var (
+	apiRequestsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "sabb_api_requests_total",
+		Help: "Solicitudes HTTP enviadas a las APIs de las plataformas.",
+	})
+	apiRetriesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "sabb_api_retries_total",
+		Help: "Reintentos de las solicitudes a las APIs.",
+	})
+	apiErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sabb_api_errors_total",
+		Help: "Errores de las APIs por código de estado (\"network\" si no hubo respuesta).",
+	}, []string{"status"})
+	programFetchSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sabb_program_fetch_duration_seconds",
+		Help:    "Latencia de la descarga del scope de cada programa.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"platform"})
+)
+
+func init() {
+	prometheus.MustRegister(apiRequestsTotal, apiRetriesTotal, apiErrorsTotal, programFetchSeconds)
+}
+
+// recordAPIError contabiliza un error de API; status 0 indica un fallo de red.
+func recordAPIError(status int) {
+	label := "network"
+	if status > 0 {
+		label = strconv.Itoa(status)
+	}
+	apiErrorsTotal.WithLabelValues(label).Inc()
+}
+
+// serveMetrics expone /metrics en addr en segundo plano durante la ejecución.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("servidor de métricas detenido: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Notificación de cambios de scope (-notify)
+ *****************/
+
+// maxNotifyAssets limita los activos listados en un mensaje; el resto solo se
+// cuenta. Los servicios de chat rechazan los mensajes demasiado largos.
+const maxNotifyAssets = 50
+
+// stringList es un flag que puede repetirse; cada aparición añade un valor.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+func flagList(name, usage string) *stringList {
+	l := new(stringList)
+	flag.Var(l, name, usage)
+	return l
+}
+
+// notifier publica los cambios de una ejecución en un servicio externo.
+type notifier struct {
+	name string
+	// withRemoved hace que el notifier reciba también los activos eliminados;
+	// el resto solo recibe los programas con activos nuevos.
+	withRemoved bool
+	send        func(ctx context.Context, changes []programChange) error
+}
+
+// parseNotifier interpreta un -notify con la forma <servicio>:<destino>.
+func parseNotifier(spec string) (notifier, error) {
+	kind, target, _ := strings.Cut(spec, ":")
+	switch strings.ToLower(kind) {
+	case "slack":
+		if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+			return notifier{}, fmt.Errorf("-notify %s: se espera slack:https://hooks.slack.com/...", spec)
+		}
+		return notifier{name: "slack", send: func(ctx context.Context, changes []programChange) error {
+			return postJSON(ctx, target, slackMessage(changes))
+		}}, nil
+	case "discord":
+		if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+			return notifier{}, fmt.Errorf("-notify %s: se espera discord:https://discord.com/api/webhooks/...", spec)
+		}
+		mention := *discordMention
+		return notifier{name: "discord", send: func(ctx context.Context, changes []programChange) error {
+			return postJSON(ctx, target, discordMessage(changes, mention))
+		}}, nil
+	case "telegram":
+		// El token del bot ya contiene ':'; el chat es lo que va tras el último.
+		i := strings.LastIndex(target, ":")
+		if i < 0 || !strings.Contains(target[:i], ":") || target[i+1:] == "" {
+			return notifier{}, errors.New("-notify telegram: se espera telegram:<bot_token>:<chat_id>")
+		}
+		token, chatID := target[:i], target[i+1:]
+		return notifier{name: "telegram", send: func(ctx context.Context, changes []programChange) error {
+			return sendTelegram(ctx, token, chatID, changes)
+		}}, nil
+	case "webhook":
+		if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+			return notifier{}, fmt.Errorf("-notify %s: se espera webhook:http(s)://...", spec)
+		}
+		return notifier{name: "webhook", withRemoved: true, send: func(ctx context.Context, changes []programChange) error {
+			return postJSON(ctx, target, newChangesPayload(changes))
+		}}, nil
+	case "email":
+		var to []string
+		for _, addr := range strings.Split(target, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		if len(to) == 0 {
+			return notifier{}, errors.New("-notify email: se espera email:<destinatario>[,<destinatario>...]")
+		}
+		if *smtpServer == "" {
+			return notifier{}, errors.New("-notify email: requiere -smtp-server")
+		}
+		cfg := smtpConfig{
+			server:   *smtpServer,
+			username: *smtpUsername,
+			password: *smtpPassword,
+			from:     *smtpFrom,
+		}
+		if cfg.from == "" {
+			cfg.from = cfg.username
+		}
+		if cfg.from == "" {
+			return notifier{}, errors.New("-notify email: requiere -smtp-from o -smtp-username")
+		}
+		return notifier{name: "email", withRemoved: true, send: func(ctx context.Context, changes []programChange) error {
+			return sendEmail(ctx, cfg, to, changes)
+		}}, nil
+	}
+	return notifier{}, fmt.Errorf("-notify %s: servicio desconocido (se espera slack:<url>, discord:<url>, telegram:<bot_token>:<chat_id>, webhook:<url> o email:<destinatario>)", spec)
+}
+
+// sendNotifications envía a cada notifier los cambios que le corresponden.
+// Si no hay ninguno no se envía nada, y los fallos solo se registran como
+// aviso.
+func sendNotifications(notifiers []notifier, changes []programChange) {
+	added := addedChanges(changes)
+	for _, n := range notifiers {
+		c := added
+		if n.withRemoved {
+			c = changes
+		}
+		if len(c) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		if err := n.send(ctx, c); err != nil {
+			log.Printf("aviso: no se pudo notificar a %s: %v", n.name, err)
+		}
+		cancel()
+	}
+}
+
+// addedChanges devuelve solo los programas con activos nuevos, sin los
+// eliminados.
+func addedChanges(changes []programChange) []programChange {
+	var out []programChange
+	for _, c := range changes {
+		if len(c.added) > 0 {
+			c.removed = nil
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// countAdded devuelve el total de activos nuevos y de programas nuevos.
+func countAdded(changes []programChange) (assets, programs int) {
+	for _, c := range changes {
+		assets += len(c.added)
+		if c.isNew {
+			programs++
+		}
+	}
+	return assets, programs
+}
+
+/*****************
+ * Slack
+ *****************/
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackMessage da formato mrkdwn a los activos nuevos, agrupados por
+// programa.
+func slackMessage(changes []programChange) slackPayload {
+	return slackPayload{Text: addedText(changes, textMarkup{
+		bold: func(s string) string { return "*" + slackEscaper.Replace(s) + "*" },
+		code: func(s string) string { return "`" + slackEscaper.Replace(s) + "`" },
+	})}
+}
+
+// textMarkup da formato a las partes de un mensaje de texto con el marcado
+// de cada servicio, que también se encarga de escaparlas.
+type textMarkup struct {
+	bold, code func(string) string
+}
+
+// addedText lista los activos nuevos agrupados por programa, hasta
+// maxNotifyAssets.
+func addedText(changes []programChange, m textMarkup) string {
+	assets, programs := countAdded(changes)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d activos nuevos", m.bold("sabb"), assets)
+	if programs > 0 {
+		fmt.Fprintf(&b, ", %d programas nuevos", programs)
+	}
+	listed := 0
+	for _, c := range changes {
+		if listed == maxNotifyAssets {
+			break
+		}
+		fmt.Fprintf(&b, "\n%s", m.bold(c.key.String()))
+		if c.isNew {
+			b.WriteString(" (programa nuevo)")
+		}
+		for _, a := range c.added {
+			if listed == maxNotifyAssets {
+				break
+			}
+			fmt.Fprintf(&b, "\n• %s", m.code(a.Identifier))
+			listed++
+		}
+	}
+	if assets > listed {
+		fmt.Fprintf(&b, "\n… y %d más", assets-listed)
+	}
+	return b.String()
+}
+
+/*****************
+ * Webhook genérico
+ *****************/
+
+// changesPayload es el JSON que -notify webhook: envía con los cambios.
+type changesPayload struct {
+	AddedAssets   int              `json:"added_assets"`
+	RemovedAssets int              `json:"removed_assets"`
+	NewPrograms   int              `json:"new_programs"`
+	Programs      []programPayload `json:"programs"`
+}
+
+type programPayload struct {
+	Platform       string  `json:"platform,omitempty"`
+	Handle         string  `json:"handle,omitempty"`
+	OffersBounties bool    `json:"offers_bounties"`
+	New            bool    `json:"new_program"`
+	Removed        bool    `json:"removed_program"`
+	Added          []Asset `json:"added"`
+	RemovedAssets  []Asset `json:"removed"`
+}
+
+func newChangesPayload(changes []programChange) changesPayload {
+	var p changesPayload
+	for _, c := range changes {
+		prog := programPayload{
+			Platform:      c.key.platform,
+			Handle:        c.key.handle,
+			New:           c.isNew,
+			Removed:       c.gone,
+			Added:         c.added,
+			RemovedAssets: c.removed,
+		}
+		// Los activos llevan los datos del programa de su ejecución.
+		if len(c.added) > 0 {
+			prog.OffersBounties = c.added[0].OffersBounties
+		} else if len(c.removed) > 0 {
+			prog.OffersBounties = c.removed[0].OffersBounties
+		}
+		if prog.Added == nil {
+			prog.Added = []Asset{}
+		}
+		if prog.RemovedAssets == nil {
+			prog.RemovedAssets = []Asset{}
+		}
+		p.AddedAssets += len(c.added)
+		p.RemovedAssets += len(c.removed)
+		if c.isNew {
+			p.NewPrograms++
+		}
+		p.Programs = append(p.Programs, prog)
+	}
+	return p
+}
+
+/*****************
+ * Correo (SMTP)
+ *****************/
+
+// smtpConfig es el servidor con el que -notify email: envía el resumen.
+type smtpConfig struct {
+	server   string // host:puerto
+	username string
+	password string
+	from     string
+}
+
+// sendEmail envía a to un resumen en texto plano de changes. En el puerto
+// 465 la conexión es TLS desde el principio; en el resto se usa STARTTLS si
+// el servidor lo ofrece. Las credenciales solo se envían cifradas, salvo a
+// localhost (lo comprueba smtp.PlainAuth).
+func sendEmail(ctx context.Context, cfg smtpConfig, to []string, changes []programChange) error {
+	host, port, err := net.SplitHostPort(cfg.server)
+	if err != nil {
+		return fmt.Errorf("-smtp-server: %w", err)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", cfg.server)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	tlsConfig := &tls.Config{ServerName: host}
+	if port == "465" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if cfg.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.username, cfg.password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(cfg.from, to, changes)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// emailMessage compone el mensaje con los mismos cambios que -diff imprime
+// en stderr, sin colores.
+func emailMessage(from string, to []string, changes []programChange) []byte {
+	added, removed := 0, 0
+	for _, c := range changes {
+		added += len(c.added)
+		removed += len(c.removed)
+	}
+	var body bytes.Buffer
+	writeDiff(&body, "la ejecución anterior", changes, colorizer{})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", fmt.Sprintf("sabb: %d activos nuevos, %d eliminados", added, removed)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+/*****************
+ * Telegram
+ *****************/
+
+// telegramAPI es la API de bots de Telegram.
+var telegramAPI = "https://api.telegram.org"
+
+type telegramPayload struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// sendTelegram envía los activos nuevos al chat chatID con el bot token. El
+// token forma parte de la URL, así que se retira de los errores.
+func sendTelegram(ctx context.Context, token, chatID string, changes []programChange) error {
+	msg := telegramPayload{
+		ChatID: chatID,
+		Text: addedText(changes, textMarkup{
+			bold: func(s string) string { return "<b>" + html.EscapeString(s) + "</b>" },
+			code: func(s string) string { return "<code>" + html.EscapeString(s) + "</code>" },
+		}),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	}
+	err := postJSON(ctx, telegramAPI+"/bot"+token+"/sendMessage", msg)
+	if err != nil {
+		return errors.New(strings.ReplaceAll(err.Error(), token, "<token>"))
+	}
+	return nil
+}
+
+/*****************
+ * Discord
+ *****************/
+
+// discordMaxEmbeds es el máximo de embeds que Discord admite por mensaje.
+const discordMaxEmbeds = 10
+
+// discordColors da a cada plataforma conocida el color de su embed.
+var discordColors = map[string]int{
+	"hackerone":   0x494649,
+	"bugcrowd":    0xf26822,
+	"intigriti":   0x161a36,
+	"yeswehack":   0x2d3be0,
+	"hackenproof": 0x1ba160,
+	"immunefi":    0x6c5ce7,
+}
+
+// discordDefaultColor es el color de las plataformas sin uno propio.
+const discordDefaultColor = 0x5865f2
+
+type discordPayload struct {
+	Content         string                 `json:"content"`
+	Embeds          []discordEmbed         `json:"embeds,omitempty"`
+	AllowedMentions discordAllowedMentions `json:"allowed_mentions"`
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+}
+
+type discordAllowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+// discordMessage agrupa los activos nuevos en un embed por plataforma. Si
+// alguno es un wildcard y se indicó -discord-mention, el mensaje lo menciona.
+func discordMessage(changes []programChange, mention string) discordPayload {
+	assets, programs := countAdded(changes)
+	content := fmt.Sprintf("**sabb**: %d activos nuevos", assets)
+	if programs > 0 {
+		content += fmt.Sprintf(", %d programas nuevos", programs)
+	}
+	msg := discordPayload{AllowedMentions: discordAllowedMentions{Parse: []string{}}}
+
+	listed := 0
+	wildcard := false
+	var b strings.Builder
+	for i, c := range changes {
+		for _, a := range c.added {
+			if a.Type == platforms.AssetTypeWildcard {
+				wildcard = true
+			}
+		}
+		if listed < maxNotifyAssets {
+			// Con una salida txt anterior no hay programas.
+			if c.key.handle != "" {
+				fmt.Fprintf(&b, "**%s**", c.key.handle)
+				if c.isNew {
+					b.WriteString(" (programa nuevo)")
+				}
+				b.WriteByte('\n')
+			}
+			for _, a := range c.added {
+				if listed == maxNotifyAssets {
+					break
+				}
+				fmt.Fprintf(&b, "`%s`\n", strings.ReplaceAll(a.Identifier, "`", "'"))
+				listed++
+			}
+		}
+		// Las plataformas llegan ordenadas: se cierra el embed al cambiar.
+		last := i == len(changes)-1 || changes[i+1].key.platform != c.key.platform
+		if last && b.Len() > 0 && len(msg.Embeds) < discordMaxEmbeds {
+			color, ok := discordColors[c.key.platform]
+			if !ok {
+				color = discordDefaultColor
+			}
+			title := c.key.platform
+			if title == "" {
+				title = "sabb"
+			}
+			msg.Embeds = append(msg.Embeds, discordEmbed{Title: title, Description: b.String(), Color: color})
+		}
+		if last {
+			b.Reset()
+		}
+	}
+	if assets > listed {
+		content += fmt.Sprintf(" (se muestran %d)", listed)
+	}
+	if wildcard && mention != "" {
+		content = mention + " " + content
+		// Discord solo avisa de las menciones permitidas explícitamente.
+		msg.AllowedMentions.Parse = []string{"users", "roles", "everyone"}
+	}
+	msg.Content = content
+	return msg
+}
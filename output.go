@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Salida
+ *****************/
+
+// dedupAssets descarta los activos cuyo identificador ya se escribió, de modo
+// que varias cuentas puedan volcar sus activos en la misma salida sin
+// duplicarlos. seen puede traer activos ya vistos (p. ej. de -state) y se
+// actualiza con cada activo nuevo.
+func dedupAssets(next AssetWriter, seen map[string]struct{}) AssetWriter {
+	if seen == nil {
+		seen = make(map[string]struct{})
+	}
+	return assetWriterFunc(func(a Asset) error {
+		if _, ok := seen[a.Identifier]; ok {
+			return nil
+		}
+		seen[a.Identifier] = struct{}{}
+		return next.WriteAsset(a)
+	})
+}
+
+// assetCounter cuenta los activos que llegan a next. No es seguro para uso
+// concurrente; se coloca detrás de syncAssetWriter.
+type assetCounter struct {
+	next AssetWriter
+	n    int
+}
+
+func (c *assetCounter) WriteAsset(a Asset) error {
+	c.n++
+	return c.next.WriteAsset(a)
+}
+
+// syncAssetWriter serializa los activos de varias plataformas concurrentes.
+type syncAssetWriter struct {
+	mu sync.Mutex
+	w  AssetWriter
+}
+
+func (s *syncAssetWriter) WriteAsset(a Asset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.WriteAsset(a)
+}
+
+// syncWriter serializa las escrituras concurrentes a un io.Writer, p. ej. el
+// archivo de exclusiones compartido por varias cuentas.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// exclusionList recoge el out_of_scope para los formatos que lo incluyen en
+// la propia salida (p. ej. el scope de Burp), sin duplicados. Es seguro para
+// uso concurrente.
+type exclusionList struct {
+	mu     sync.Mutex
+	assets []Asset
+	seen   map[string]bool
+}
+
+func (l *exclusionList) WriteAsset(a Asset) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.seen == nil {
+		l.seen = make(map[string]bool)
+	}
+	if !l.seen[a.Identifier] {
+		l.seen[a.Identifier] = true
+		l.assets = append(l.assets, a)
+	}
+	return nil
+}
+
+// list devuelve las exclusiones recogidas, en orden de llegada.
+func (l *exclusionList) list() []Asset {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Asset(nil), l.assets...)
+}
+
+// exclusionLines escribe cada exclusión como "!<activo>" (-emit-exclusions).
+func exclusionLines(w io.Writer) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		_, err := fmt.Fprintln(w, "!"+a.Identifier)
+		return err
+	})
+}
+
+// teeAssets escribe cada activo en todos los writers.
+func teeAssets(writers ...AssetWriter) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		for _, w := range writers {
+			if err := w.WriteAsset(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parseFileMode interpreta los permisos del archivo de salida en octal (p. ej. "0644").
+func parseFileMode(s string) (os.FileMode, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil || v > 0777 {
+		return 0, fmt.Errorf("permisos inválidos %q: se espera un valor octal entre 0000 y 0777", s)
+	}
+	return os.FileMode(v), nil
+}
+
+// openOutputFile abre (o crea) el archivo de salida, creando antes los
+// directorios padre que falten. Con appendOutput se añade a lo que ya
+// contenga; si no, se vacía (ver formatAppends).
+func openOutputFile(path string, mode os.FileMode, appendOutput bool) (*os.File, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendOutput {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, mode)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// programSplitter escribe los activos de cada programa en su propio archivo
+// <dir>/<plataforma>/<handle><ext> (-split-by-program), con el codificador de
+// -format (solo formatos de una línea por activo). Cada plataforma tiene
+// abierto el archivo de su programa actual, que se cierra al pasar al
+// siguiente, de modo que lo escrito llega al disco programa a programa como
+// con la salida normal. No es seguro para uso concurrente; se coloca detrás
+// de syncAssetWriter.
+type programSplitter struct {
+	dir  string
+	ext  string
+	mode os.FileMode
+	// appendOutput añade a los archivos que ya existan al abrirlos por primera
+	// vez en la ejecución; después siempre se añade.
+	appendOutput bool
+	fsync        bool
+	enc          assetEncoder
+	// target es el archivo del activo que se está codificando.
+	target *bufio.Writer
+	open   map[string]*splitFile // por plataforma
+	opened map[programKey]bool
+}
+
+// splitFile es el archivo abierto del programa actual de una plataforma.
+type splitFile struct {
+	key programKey
+	f   *os.File
+	w   *bufio.Writer
+}
+
+func newProgramSplitter(dir, format string, mode os.FileMode, appendOutput, fsync bool, opts encoderOptions) (*programSplitter, error) {
+	f, ok := lookupFormat(format)
+	if !ok || !f.lines {
+		return nil, fmt.Errorf("-split-by-program no admite -format %s: usa un formato de una línea por activo (txt, jsonl, urls o targets)", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+	}
+	p := &programSplitter{
+		dir:          dir,
+		ext:          ".txt",
+		mode:         mode,
+		appendOutput: appendOutput,
+		fsync:        fsync,
+		open:         make(map[string]*splitFile),
+		opened:       make(map[programKey]bool),
+	}
+	if len(f.extensions) > 0 {
+		p.ext = f.extensions[0]
+	}
+	p.enc = f.newEncoder(p, opts)
+	return p, nil
+}
+
+// Write recibe la salida del codificador y la dirige al archivo actual.
+func (p *programSplitter) Write(b []byte) (int, error) { return p.target.Write(b) }
+
+func (p *programSplitter) WriteAsset(a Asset) error {
+	key := programKey{a.Platform, a.Handle}
+	sf := p.open[a.Platform]
+	if sf == nil || sf.key != key {
+		if sf != nil {
+			delete(p.open, a.Platform)
+			if err := p.closeFile(sf); err != nil {
+				return err
+			}
+		}
+		path := filepath.Join(p.dir, platforms.SafeFileName(a.Platform), platforms.SafeFileName(a.Handle)+p.ext)
+		f, err := openOutputFile(path, p.mode, p.appendOutput || p.opened[key])
+		if err != nil {
+			return err
+		}
+		p.opened[key] = true
+		sf = &splitFile{key: key, f: f, w: bufio.NewWriter(f)}
+		p.open[a.Platform] = sf
+	}
+	p.target = sf.w
+	return p.enc.WriteAsset(a)
+}
+
+// closeFile vacía y cierra el archivo de un programa; con -fsync antes lo
+// sincroniza con el disco.
+func (p *programSplitter) closeFile(sf *splitFile) error {
+	if err := sf.w.Flush(); err != nil {
+		sf.f.Close()
+		return fmt.Errorf("no se pudo escribir %s: %w", sf.f.Name(), err)
+	}
+	if p.fsync {
+		if err := sf.f.Sync(); err != nil {
+			sf.f.Close()
+			return fmt.Errorf("no se pudo sincronizar %s: %w", sf.f.Name(), err)
+		}
+	}
+	return sf.f.Close()
+}
+
+func (p *programSplitter) Close() error {
+	err := p.enc.Close()
+	for platform, sf := range p.open {
+		delete(p.open, platform)
+		if cerr := p.closeFile(sf); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// outputDestination es el destino final de la salida (-output).
+type outputDestination interface {
+	io.Writer
+	// Close entrega la salida: sincroniza y cierra el archivo, o sube el
+	// contenido acumulado al destino remoto.
+	Close() error
+}
+
+// remoteUploadTimeout acota la subida a S3/HTTP, que se hace al final de la
+// ejecución aunque el timeout total ya haya vencido.
+const remoteUploadTimeout = 2 * time.Minute
+
+// openOutput abre el destino indicado en -output: "-" para stdout,
+// s3://bucket/key, http(s)://... (POST del resultado completo) o una ruta local,
+// que se abre en modo append solo con appendOutput. contentType es el del
+// formato (formatContentType) y solo se usa en los destinos remotos.
+func openOutput(dest string, mode os.FileMode, appendOutput bool, contentType string) (outputDestination, error) {
+	switch {
+	case dest == "-":
+		return stdoutDestination{}, nil
+	case strings.HasPrefix(dest, "s3://"):
+		bucket, key, ok := strings.Cut(strings.TrimPrefix(dest, "s3://"), "/")
+		if !ok || bucket == "" || key == "" {
+			return nil, fmt.Errorf("destino S3 inválido %q: se espera s3://bucket/key", dest)
+		}
+		return &remoteDestination{name: dest, upload: func(ctx context.Context, body []byte) error {
+			return uploadS3(ctx, bucket, key, contentType, body)
+		}}, nil
+	case strings.HasPrefix(dest, "http://"), strings.HasPrefix(dest, "https://"):
+		return &remoteDestination{name: dest, upload: func(ctx context.Context, body []byte) error {
+			return postOutput(ctx, dest, contentType, body)
+		}}, nil
+	}
+	f, err := openOutputFile(dest, mode, appendOutput)
+	if err != nil {
+		return nil, err
+	}
+	return fileDestination{f}, nil
+}
+
+type fileDestination struct{ *os.File }
+
+func (f fileDestination) Close() error {
+	// Solo se sincronizan archivos regulares: /dev/null o un FIFO no lo admiten.
+	if fi, err := f.Stat(); err == nil && !fi.Mode().IsRegular() {
+		return f.File.Close()
+	}
+	if err := f.Sync(); err != nil {
+		f.File.Close()
+		return fmt.Errorf("no se pudo sincronizar %s: %w", f.Name(), err)
+	}
+	return f.File.Close()
+}
+
+// programFlusher vacía la salida cada vez que un fetcher pasa al siguiente
+// programa, para que lo ya escrito sobreviva a un fallo o a un kill a mitad de
+// la ejecución. Con sync (-fsync) además sincroniza el archivo con el disco.
+// Los formatos que solo escriben al cerrar no ganan nada con ello.
+type programFlusher struct {
+	mu   sync.Mutex
+	enc  assetEncoder
+	w    *bufio.Writer
+	sync func() error
+}
+
+func newProgramFlusher(enc assetEncoder, w *bufio.Writer, dst outputDestination, fsync bool) *programFlusher {
+	f := &programFlusher{enc: enc, w: w}
+	if fd, ok := dst.(fileDestination); ok && fsync {
+		if fi, err := fd.Stat(); err == nil && fi.Mode().IsRegular() {
+			f.sync = fd.Sync
+		}
+	}
+	return f
+}
+
+func (f *programFlusher) WriteAsset(a Asset) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.enc.WriteAsset(a)
+}
+
+func (f *programFlusher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.enc.Close()
+}
+
+// flush no devuelve errores: los de escritura se repiten y se informan al
+// cerrar la salida.
+func (f *programFlusher) flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fl, ok := f.enc.(interface{ Flush() error }); ok {
+		fl.Flush()
+	}
+	if f.w.Flush() == nil && f.sync != nil {
+		f.sync()
+	}
+}
+
+type stdoutDestination struct{}
+
+func (stdoutDestination) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+func (stdoutDestination) Close() error                { return nil }
+
+// remoteDestination acumula la salida en memoria y la entrega al cerrar.
+type remoteDestination struct {
+	name   string
+	buf    bytes.Buffer
+	upload func(ctx context.Context, body []byte) error
+}
+
+func (r *remoteDestination) Write(p []byte) (int, error) { return r.buf.Write(p) }
+
+func (r *remoteDestination) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteUploadTimeout)
+	defer cancel()
+	if err := r.upload(ctx, r.buf.Bytes()); err != nil {
+		return fmt.Errorf("no se pudo entregar la salida a %s: %w", r.name, err)
+	}
+	return nil
+}
+
+// uploadS3 sube body a s3://bucket/key con las credenciales de la cadena por
+// defecto de AWS (variables de entorno, perfil compartido, rol de instancia).
+func uploadS3(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("configuración AWS: %w", err)
+	}
+	_, err = s3.NewFromConfig(cfg).PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// postOutput envía body por POST a url y falla ante cualquier estado no 2xx.
+func postOutput(ctx context.Context, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("el servidor respondió %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
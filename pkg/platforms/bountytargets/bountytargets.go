@@ -0,0 +1,254 @@
+// Package bountytargets importa los volcados comunitarios de
+// arkadiyt/bounty-targets-data.
+package bountytargets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/bugcrowd"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/hackerone"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/intigriti"
+)
+
+/*****************
+ * bounty-targets-data (arkadiyt)
+ *****************/
+
+// baseURL es la raíz de los volcados JSON del proyecto
+// comunitario arkadiyt/bounty-targets-data, actualizados a diario.
+const baseURL = "https://raw.githubusercontent.com/arkadiyt/bounty-targets-data/main/data/"
+
+// Fetcher importa los volcados de bounty-targets-data: una forma
+// de obtener scopes de varias plataformas sin credenciales. Cada activo
+// conserva la plataforma de origen.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe el out_of_scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+	// Eligibility aplica -eligibility a los datos de HackerOne, los únicos que
+	// distinguen bounty de submission.
+	Eligibility string
+}
+
+// Estructuras de cada volcado; solo se modela lo necesario y se decodifican
+// siempre en modo laxo, porque -strict es una ayuda para la API, no para
+// estos archivos de terceros.
+type hackerOneProgram struct {
+	Handle         string `json:"handle"`
+	OffersBounties bool   `json:"offers_bounties"`
+	Targets        struct {
+		InScope []struct {
+			AssetIdentifier       string `json:"asset_identifier"`
+			AssetType             string `json:"asset_type"`
+			Instruction           string `json:"instruction"`
+			EligibleForBounty     bool   `json:"eligible_for_bounty"`
+			EligibleForSubmission bool   `json:"eligible_for_submission"`
+		} `json:"in_scope"`
+		OutOfScope []struct {
+			AssetIdentifier string `json:"asset_identifier"`
+		} `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+type target struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
+}
+
+type bugcrowdProgram struct {
+	URL       string  `json:"url"`
+	MaxPayout float64 `json:"max_payout"`
+	Targets   struct {
+		InScope    []target `json:"in_scope"`
+		OutOfScope []target `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+type intigritiProgram struct {
+	Handle    string `json:"handle"`
+	MaxBounty struct {
+		Value float64 `json:"value"`
+	} `json:"max_bounty"`
+	Targets struct {
+		InScope []struct {
+			Type        string `json:"type"`
+			Endpoint    string `json:"endpoint"`
+			Description string `json:"description"`
+		} `json:"in_scope"`
+		OutOfScope []struct {
+			Endpoint string `json:"endpoint"`
+		} `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+type federacyProgram struct {
+	URL          string `json:"url"`
+	OffersAwards bool   `json:"offers_awards"`
+	Targets      struct {
+		InScope    []target `json:"in_scope"`
+		OutOfScope []target `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	sources := []struct {
+		file  string
+		parse func([]byte) ([]platforms.Program, error)
+	}{
+		{"hackerone_data.json", f.parseHackerOne},
+		{"bugcrowd_data.json", parseBugcrowd},
+		{"intigriti_data.json", parseIntigriti},
+		{"federacy_data.json", parseFederacy},
+	}
+
+	processed := 0
+	for _, src := range sources {
+		start := time.Now()
+		body, err := platforms.DoRequestWithRetry(ctx, client, baseURL+src.file, nil)
+		platforms.ObserveProgramFetch("bountytargets", start)
+		if err != nil {
+			return processed, fmt.Errorf("%s request failed: %w", src.file, err)
+		}
+		programs, err := src.parse(body)
+		if err != nil {
+			return processed, fmt.Errorf("%s: %w", src.file, err)
+		}
+		for _, p := range programs {
+			if err := ctx.Err(); err != nil {
+				return processed, err
+			}
+			if !p.OffersBounties && !f.IncludeVDP {
+				continue
+			}
+			platforms.Processing(p.Handle)
+			if err := platforms.WriteProgram(out, f.Exclusions, p); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+func (f Fetcher) parseHackerOne(data []byte) ([]platforms.Program, error) {
+	var raw []hackerOneProgram
+	if err := platforms.SafeUnmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	programs := make([]platforms.Program, 0, len(raw))
+	for _, r := range raw {
+		p := platforms.Program{Platform: "hackerone", Handle: r.Handle, OffersBounties: r.OffersBounties}
+		for _, t := range r.Targets.InScope {
+			if !hackerone.Eligible(f.Eligibility, t.EligibleForBounty, t.EligibleForSubmission) {
+				continue
+			}
+			eligible := t.EligibleForBounty
+			p.Assets = append(p.Assets, platforms.Asset{
+				Platform:          "hackerone",
+				Identifier:        t.AssetIdentifier,
+				Type:              t.AssetType,
+				Instruction:       t.Instruction,
+				EligibleForBounty: &eligible,
+			})
+		}
+		for _, t := range r.Targets.OutOfScope {
+			p.OutOfScope = append(p.OutOfScope, platforms.Asset{Platform: "hackerone", Identifier: t.AssetIdentifier})
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+func parseBugcrowd(data []byte) ([]platforms.Program, error) {
+	var raw []bugcrowdProgram
+	if err := platforms.SafeUnmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	programs := make([]platforms.Program, 0, len(raw))
+	for _, r := range raw {
+		programs = append(programs, platforms.Program{
+			Platform:       "bugcrowd",
+			Handle:         handleFromURL(r.URL),
+			OffersBounties: r.MaxPayout > 0,
+			Assets:         targetAssets("bugcrowd", r.Targets.InScope),
+			OutOfScope:     targetAssets("bugcrowd", r.Targets.OutOfScope),
+		})
+	}
+	return programs, nil
+}
+
+func parseIntigriti(data []byte) ([]platforms.Program, error) {
+	var raw []intigritiProgram
+	if err := platforms.SafeUnmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	programs := make([]platforms.Program, 0, len(raw))
+	for _, r := range raw {
+		p := platforms.Program{Platform: "intigriti", Handle: r.Handle, OffersBounties: r.MaxBounty.Value > 0}
+		for _, t := range r.Targets.InScope {
+			p.Assets = append(p.Assets, platforms.Asset{
+				Platform:    "intigriti",
+				Identifier:  t.Endpoint,
+				Type:        intigriti.AssetType(t.Type),
+				Instruction: t.Description,
+			})
+		}
+		for _, t := range r.Targets.OutOfScope {
+			p.OutOfScope = append(p.OutOfScope, platforms.Asset{Platform: "intigriti", Identifier: t.Endpoint})
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+func parseFederacy(data []byte) ([]platforms.Program, error) {
+	var raw []federacyProgram
+	if err := platforms.SafeUnmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	programs := make([]platforms.Program, 0, len(raw))
+	for _, r := range raw {
+		programs = append(programs, platforms.Program{
+			Platform:       "federacy",
+			Handle:         handleFromURL(r.URL),
+			OffersBounties: r.OffersAwards,
+			Assets:         targetAssets("federacy", r.Targets.InScope),
+			OutOfScope:     targetAssets("federacy", r.Targets.OutOfScope),
+		})
+	}
+	return programs, nil
+}
+
+// targetAssets convierte los objetivos con forma {type, target} de
+// Bugcrowd y Federacy, cuyas categorías coinciden.
+func targetAssets(platform string, targets []target) []platforms.Asset {
+	assets := make([]platforms.Asset, 0, len(targets))
+	for _, t := range targets {
+		assets = append(assets, platforms.Asset{
+			Platform:   platform,
+			Identifier: t.Target,
+			Type:       bugcrowd.AssetType(t.Type, t.Target),
+		})
+	}
+	return assets
+}
+
+// handleFromURL toma el último segmento de la URL de un programa como handle,
+// p. ej. https://bugcrowd.com/tesla -> tesla.
+func handleFromURL(u string) string {
+	return path.Base(strings.TrimRight(u, "/"))
+}
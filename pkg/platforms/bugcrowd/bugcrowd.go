@@ -0,0 +1,223 @@
+// Package bugcrowd descarga el scope de los engagements de Bugcrowd con un
+// token de la API.
+package bugcrowd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Bugcrowd
+ *****************/
+
+// pageLimit es el tamaño de página de los listados de Bugcrowd.
+const pageLimit = 100
+
+// Fetcher descarga el scope de los engagements de Bugcrowd a los que
+// tiene acceso el token: recorre los engagements y, de cada uno, sus grupos de
+// objetivos con los objetivos incluidos.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe los objetivos fuera de scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los engagements sin recompensas.
+	IncludeVDP bool
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+	// MaxPages limita las páginas del listado de engagements y de los grupos
+	// de objetivos de cada uno (0 = sin límite).
+	MaxPages int
+}
+
+type engagementsPage struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Code          string `json:"code"`
+			Name          string `json:"name"`
+			OffersRewards bool   `json:"offers_rewards"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// targetGroupsPage es la respuesta de target_groups con include=targets:
+// cada grupo referencia sus objetivos, que llegan completos en included.
+type targetGroupsPage struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			Name    string `json:"name"`
+			InScope bool   `json:"in_scope"`
+		} `json:"attributes"`
+		Relationships struct {
+			Targets struct {
+				Data []struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			} `json:"targets"`
+		} `json:"relationships"`
+	} `json:"data"`
+	Included []struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			Name     string `json:"name"`
+			URI      string `json:"uri"`
+			Category string `json:"category"`
+		} `json:"attributes"`
+	} `json:"included"`
+}
+
+func (b Fetcher) Fetch(ctx context.Context, creds platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := b.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if creds.Token == "" {
+		return 0, fmt.Errorf("%w: Bugcrowd requiere un token", platforms.ErrInvalidCredentials)
+	}
+	header := http.Header{
+		"Authorization": {"Token " + creds.Token},
+		"Accept":        {"application/vnd.bugcrowd+json"},
+	}
+
+	processed := 0
+	for page := 0; ; page++ {
+		select {
+		case <-ctx.Done():
+			return processed, ctx.Err()
+		default:
+		}
+		if b.MaxPages > 0 && page >= b.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de engagements (-max-pages)", b.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		url := fmt.Sprintf("https://api.bugcrowd.com/engagements?page[limit]=%d&page[offset]=%d", pageLimit, page*pageLimit)
+		body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+		if err != nil {
+			return processed, fmt.Errorf("engagements page request failed: %w", err)
+		}
+		var pg engagementsPage
+		if err := platforms.Unmarshal(body, &pg, b.Strict); err != nil {
+			return processed, err
+		}
+		if len(pg.Data) == 0 {
+			break
+		}
+
+		for _, e := range pg.Data {
+			if !e.Attributes.OffersRewards && !b.IncludeVDP {
+				continue
+			}
+			handle := e.Attributes.Code
+			platforms.Processing(handle)
+
+			start := time.Now()
+			assets, excluded, err := b.fetchTargets(ctx, client, header, e.ID)
+			platforms.ObserveProgramFetch("bugcrowd", start)
+			if err != nil {
+				if platforms.IsUnavailableScope(err) {
+					log.Printf("aviso: se omite %s: scope no disponible (%v)", handle, err)
+					continue
+				}
+				return processed, fmt.Errorf("engagement %s failed: %w", handle, err)
+			}
+			if err := platforms.WriteProgram(out, b.Exclusions, platforms.Program{Platform: "bugcrowd", Handle: handle, OffersBounties: e.Attributes.OffersRewards, Assets: assets, OutOfScope: excluded}); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+// fetchTargets devuelve los objetivos de los grupos en scope de un engagement
+// y, por separado, los de los grupos fuera de scope.
+func (b Fetcher) fetchTargets(ctx context.Context, client *http.Client, header http.Header, engagementID string) (assets, excluded []platforms.Asset, err error) {
+	for page := 0; ; page++ {
+		// La misma red de seguridad que el listado: una API que siempre
+		// devuelve datos no debe paginar sin fin.
+		if b.MaxPages > 0 && page >= b.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de objetivos en %s (-max-pages)", b.MaxPages, engagementID)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+		url := fmt.Sprintf("https://api.bugcrowd.com/engagements/%s/target_groups?include=targets&page[limit]=%d&page[offset]=%d", engagementID, pageLimit, page*pageLimit)
+		body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+		if err != nil {
+			return nil, nil, err
+		}
+		var pg targetGroupsPage
+		if err := platforms.Unmarshal(body, &pg, b.Strict); err != nil {
+			return nil, nil, err
+		}
+		if len(pg.Data) == 0 {
+			break
+		}
+
+		targets := make(map[string]platforms.Asset, len(pg.Included))
+		for _, t := range pg.Included {
+			if t.Type != "target" {
+				continue
+			}
+			id := t.Attributes.URI
+			if id == "" {
+				id = t.Attributes.Name
+			}
+			targets[t.ID] = platforms.Asset{
+				Platform:   "bugcrowd",
+				Identifier: id,
+				Type:       AssetType(t.Attributes.Category, id),
+			}
+		}
+		for _, g := range pg.Data {
+			for _, ref := range g.Relationships.Targets.Data {
+				asset, ok := targets[ref.ID]
+				if !ok {
+					continue
+				}
+				if g.Attributes.InScope {
+					assets = append(assets, asset)
+				} else {
+					excluded = append(excluded, asset)
+				}
+			}
+		}
+	}
+	return assets, excluded, nil
+}
+
+// AssetType traduce la categoría de un objetivo de Bugcrowd a los
+// tipos de activo de HackerOne que usa el resto de la herramienta.
+func AssetType(category, identifier string) string {
+	switch strings.ToLower(category) {
+	case "website", "api":
+		if strings.HasPrefix(identifier, "*.") {
+			return platforms.AssetTypeWildcard
+		}
+		return platforms.AssetTypeURL
+	case "android":
+		return "GOOGLE_PLAY_APP_ID"
+	case "ios":
+		return "APPLE_STORE_APP_ID"
+	case "network":
+		if strings.Contains(identifier, "/") {
+			return platforms.AssetTypeCIDR
+		}
+		return platforms.AssetTypeIPAddress
+	case "":
+		return "OTHER"
+	}
+	return strings.ToUpper(category)
+}
@@ -0,0 +1,51 @@
+package bugcrowd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// rewriteTransport envía a target las solicitudes dirigidas a la API real,
+// conservando la ruta y la query.
+type rewriteTransport struct{ target *url.URL }
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetchTargetsStopsAtMaxPages(t *testing.T) {
+	// Una API defectuosa que devuelve datos en todas las páginas.
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 10 {
+			http.Error(w, "demasiadas páginas", http.StatusTeapot)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":[{"id":"g1","attributes":{"in_scope":true},"relationships":{"targets":{"data":[{"id":"t1"}]}}}],
+			"included":[{"id":"t1","type":"target","attributes":{"uri":"*.acme.com","category":"website"}}]}`)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	b := Fetcher{MaxPages: 3}
+	client := &http.Client{Transport: rewriteTransport{target}}
+	assets, _, err := b.fetchTargets(context.Background(), client, http.Header{}, "acme")
+	if err != nil {
+		t.Fatalf("fetchTargets: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("páginas pedidas = %d, se esperaba 3 (-max-pages)", requests)
+	}
+	if len(assets) != 3 {
+		t.Errorf("activos = %d, se esperaba uno por página", len(assets))
+	}
+}
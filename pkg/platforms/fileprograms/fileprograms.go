@@ -0,0 +1,113 @@
+// Package fileprograms lee una lista de programas mantenida a mano en un
+// archivo JSON o YAML.
+package fileprograms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Programas desde archivo (-program file:<ruta>)
+ *****************/
+
+// Fetcher lee una lista de programas mantenida a mano (invitaciones
+// privadas, programas propios...) y la pasa por la misma salida que el resto
+// de plataformas. Formato:
+//
+//	programs:
+//	  - handle: acme
+//	    platform: privado      # opcional, por defecto "file"
+//	    offers_bounties: true
+//	    assets:
+//	      - identifier: "*.acme.com"
+//	        type: WILDCARD     # opcional, se deduce del identificador
+//	        instruction: sin pruebas de carga
+//	    out_of_scope:
+//	      - blog.acme.com
+type Fetcher struct {
+	// Path es el archivo; con extensión .json se lee como JSON y con
+	// cualquier otra como YAML.
+	Path string
+	// Exclusions, si no es nil, recibe el out_of_scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+}
+
+type programList struct {
+	Programs []struct {
+		Handle         string `json:"handle" yaml:"handle"`
+		Platform       string `json:"platform" yaml:"platform"`
+		OffersBounties bool   `json:"offers_bounties" yaml:"offers_bounties"`
+		Assets         []struct {
+			Identifier  string `json:"identifier" yaml:"identifier"`
+			Type        string `json:"type" yaml:"type"`
+			Instruction string `json:"instruction" yaml:"instruction"`
+		} `json:"assets" yaml:"assets"`
+		OutOfScope []string `json:"out_of_scope" yaml:"out_of_scope"`
+	} `json:"programs" yaml:"programs"`
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return 0, err
+	}
+	var list programList
+	switch strings.ToLower(filepath.Ext(f.Path)) {
+	case ".json":
+		err = platforms.SafeUnmarshal(data, &list)
+	default:
+		// JSON también es YAML válido, así que cualquier otra extensión se
+		// lee como YAML.
+		err = yaml.Unmarshal(data, &list)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", f.Path, err)
+	}
+
+	processed := 0
+	for i, p := range list.Programs {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		if p.Handle == "" {
+			return processed, fmt.Errorf("%s: program #%d has no handle", f.Path, i+1)
+		}
+		if !p.OffersBounties && !f.IncludeVDP {
+			continue
+		}
+		platform := p.Platform
+		if platform == "" {
+			platform = "file"
+		}
+		platforms.Processing(p.Handle)
+
+		assets := make([]platforms.Asset, 0, len(p.Assets))
+		for _, a := range p.Assets {
+			id := strings.TrimSpace(a.Identifier)
+			t := a.Type
+			if t == "" {
+				t = platforms.GuessAssetType(id)
+			}
+			assets = append(assets, platforms.Asset{Platform: platform, Identifier: id, Type: strings.ToUpper(t), Instruction: a.Instruction})
+		}
+		excluded := make([]platforms.Asset, 0, len(p.OutOfScope))
+		for _, id := range p.OutOfScope {
+			excluded = append(excluded, platforms.Asset{Platform: platform, Identifier: strings.TrimSpace(id)})
+		}
+		if err := platforms.WriteProgram(out, f.Exclusions, platforms.Program{Platform: platform, Handle: p.Handle, OffersBounties: p.OffersBounties, Assets: assets, OutOfScope: excluded}); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
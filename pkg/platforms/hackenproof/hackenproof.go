@@ -0,0 +1,174 @@
+// Package hackenproof descarga el scope de los programas públicos de
+// HackenProof.
+package hackenproof
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * HackenProof
+ *****************/
+
+// Fetcher descarga el scope de los programas públicos de
+// HackenProof. Los datos son públicos: no necesita credenciales.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe los objetivos fuera de scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+	// MaxPages limita las páginas del listado de programas (0 = sin límite).
+	MaxPages int
+}
+
+type programsPage struct {
+	Data []struct {
+		Slug  string `json:"slug"`
+		Title string `json:"title"`
+		// Type es "bug_bounty" en los programas con recompensas.
+		Type string `json:"type"`
+	} `json:"data"`
+	Meta struct {
+		TotalPages int `json:"total_pages"`
+	} `json:"meta"`
+}
+
+type target struct {
+	Target      string `json:"target"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type scope struct {
+	InScope    []target `json:"in_scope"`
+	OutOfScope []target `json:"out_of_scope"`
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	processed := 0
+	for page := 1; ; page++ {
+		select {
+		case <-ctx.Done():
+			return processed, ctx.Err()
+		default:
+		}
+		if f.MaxPages > 0 && page > f.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de programas de HackenProof (-max-pages)", f.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		url := fmt.Sprintf("https://hackenproof.com/api/v1/programs?page=%d", page)
+		body, err := platforms.DoRequestWithRetry(ctx, client, url, nil)
+		if err != nil {
+			return processed, fmt.Errorf("programs page request failed: %w", err)
+		}
+		var pg programsPage
+		if err := platforms.Unmarshal(body, &pg, f.Strict); err != nil {
+			return processed, err
+		}
+		if len(pg.Data) == 0 {
+			break
+		}
+
+		for _, p := range pg.Data {
+			offersBounties := p.Type == "bug_bounty"
+			if !offersBounties && !f.IncludeVDP {
+				continue
+			}
+			handle := p.Slug
+			platforms.Processing(handle)
+
+			start := time.Now()
+			assets, excluded, err := f.fetchScope(ctx, client, handle)
+			platforms.ObserveProgramFetch("hackenproof", start)
+			if err != nil {
+				if platforms.IsUnavailableScope(err) {
+					log.Printf("aviso: se omite %s: scope no disponible (%v)", handle, err)
+					continue
+				}
+				return processed, fmt.Errorf("program %s failed: %w", handle, err)
+			}
+			if err := platforms.WriteProgram(out, f.Exclusions, platforms.Program{Platform: "hackenproof", Handle: handle, OffersBounties: offersBounties, Assets: assets, OutOfScope: excluded}); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+
+		if pg.Meta.TotalPages > 0 && page >= pg.Meta.TotalPages {
+			break
+		}
+	}
+	return processed, nil
+}
+
+// fetchScope devuelve los objetivos en scope de un programa y, por separado,
+// los fuera de scope.
+func (f Fetcher) fetchScope(ctx context.Context, client *http.Client, slug string) (assets, excluded []platforms.Asset, err error) {
+	url := fmt.Sprintf("https://hackenproof.com/api/v1/programs/%s/scopes", slug)
+	body, err := platforms.DoRequestWithRetry(ctx, client, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var scope scope
+	if err := platforms.Unmarshal(body, &scope, f.Strict); err != nil {
+		return nil, nil, err
+	}
+
+	for _, t := range scope.InScope {
+		assets = append(assets, newAsset(t))
+	}
+	for _, t := range scope.OutOfScope {
+		excluded = append(excluded, newAsset(t))
+	}
+	return assets, excluded, nil
+}
+
+func newAsset(t target) platforms.Asset {
+	id := strings.TrimSpace(t.Target)
+	return platforms.Asset{
+		Platform:    "hackenproof",
+		Identifier:  id,
+		Type:        assetType(t.Type, id),
+		Instruction: t.Description,
+	}
+}
+
+// assetType traduce el tipo de objetivo de HackenProof a los tipos
+// de activo de HackerOne que usa el resto de la herramienta. Los contratos
+// inteligentes, frecuentes en esta plataforma, se marcan como SMART_CONTRACT.
+func assetType(t, identifier string) string {
+	switch strings.ToLower(t) {
+	case "web", "api":
+		if strings.HasPrefix(identifier, "*.") {
+			return platforms.AssetTypeWildcard
+		}
+		return platforms.AssetTypeURL
+	case "smart_contract":
+		return platforms.AssetTypeSmartContract
+	case "source_code":
+		return "SOURCE_CODE"
+	case "android":
+		return "GOOGLE_PLAY_APP_ID"
+	case "ios":
+		return "APPLE_STORE_APP_ID"
+	}
+	return "OTHER"
+}
@@ -0,0 +1,476 @@
+// Package hackerone descarga el scope de los programas de HackerOne, con la
+// API de hackers (username y token) o, sin credenciales, desde el directorio
+// público.
+package hackerone
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*************************
+ * HackerOne implementation
+ *************************/
+
+// Fetcher descarga el scope de los programas de HackerOne. El valor cero
+// recorre el listado de la API y emite los activos elegibles para bounty.
+type Fetcher struct {
+	// Collapse omite los hostnames ya cubiertos por un wildcard del mismo programa.
+	Collapse bool
+	// IncludeVDP procesa también los programas sin recompensas (solo VDP).
+	IncludeVDP bool
+	// Client se comparte entre cuentas para que los reintentos y las conexiones
+	// usen la misma maquinaria; si es nil se crea uno por ejecución.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe los activos fuera de scope.
+	Exclusions platforms.AssetWriter
+	// PageSize es el tamaño de página del listado de programas (1–100).
+	PageSize int
+	// InstructionContains/InstructionExcludes filtran los activos por el texto
+	// de su instrucción de scope (sin distinguir mayúsculas).
+	InstructionContains string
+	InstructionExcludes string
+	// StrictHandles aborta ante un 404/403 en el scope de un programa en vez
+	// de omitirlo.
+	StrictHandles bool
+	// Since omite los programas actualizados antes de ahora-since (0 = todos).
+	Since time.Duration
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+	// MaxPages limita las páginas del listado de programas y del scope de
+	// cada uno (0 = sin límite).
+	MaxPages int
+	// MinBounty omite los programas cuya recompensa máxima publicada es menor
+	// (0 = sin filtro). Los programas sin tabla de recompensas se incluyen
+	// salvo que RequireBountyTable esté activo.
+	MinBounty          float64
+	RequireBountyTable bool
+	// Eligibility decide qué activos del scope se emiten: EligibilityBounty
+	// (por defecto), EligibilitySubmission o EligibilityAny.
+	Eligibility string
+	// Scopes, si no es nil, guarda los scopes ya descargados para no repetir
+	// la consulta de un mismo programa desde otra cuenta.
+	Scopes *platforms.ScopeCache
+	// Snapshot, si no es nil, reutiliza entre ejecuciones el scope de los
+	// programas no actualizados (updated_at) desde la última descarga.
+	Snapshot *platforms.ScopeSnapshot
+	// Raw, si no es nil, recibe las respuestas crudas de cada programa.
+	Raw *RawStore
+	// Handle, si no está vacío, limita la ejecución a ese único programa sin
+	// recorrer el listado.
+	Handle string
+	// Verbose registra cada programa omitido y el motivo.
+	Verbose bool
+	// Public usa el directorio público de HackerOne, sin credenciales, en vez
+	// de la API.
+	Public bool
+	// Skip, si no es nil, omite sin descargar su scope los programas del
+	// listado para los que devuelve true (p. ej. los ya escritos antes de
+	// reanudar una ejecución).
+	Skip func(handle string) bool
+	// Concurrency es el número de scopes que se descargan a la vez (0 o 1 =
+	// de uno en uno). Los activos se escriben igualmente en el orden del
+	// listado.
+	Concurrency int
+}
+
+// Valores de -eligibility.
+const (
+	EligibilityBounty     = "bounty"
+	EligibilitySubmission = "submission"
+	EligibilityAny        = "any"
+)
+
+// ParseEligibility valida el valor de -eligibility.
+func ParseEligibility(s string) (string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case EligibilityBounty, EligibilitySubmission, EligibilityAny:
+		return s, nil
+	}
+	return "", fmt.Errorf("elegibilidad desconocida %q: se admite bounty, submission o any", s)
+}
+
+// Eligible indica si un activo con esas marcas de elegibilidad se emite según
+// eligibility (un valor de ParseEligibility). Sin valor se usa
+// EligibilityBounty.
+func Eligible(eligibility string, bounty, submission bool) bool {
+	switch eligibility {
+	case EligibilitySubmission:
+		return submission
+	case EligibilityAny:
+		return bounty || submission
+	default:
+		return bounty
+	}
+}
+
+// Rango de page[size] admitido por la API de HackerOne.
+const (
+	MinPageSize = 1
+	MaxPageSize = 100
+)
+
+// ClampPageSize ajusta el tamaño de página al rango admitido, avisando si cambia.
+func ClampPageSize(n int) int {
+	switch {
+	case n < MinPageSize:
+		log.Printf("aviso: -page-size %d fuera de rango, se usa %d", n, MinPageSize)
+		return MinPageSize
+	case n > MaxPageSize:
+		log.Printf("aviso: -page-size %d fuera de rango, se usa %d", n, MaxPageSize)
+		return MaxPageSize
+	}
+	return n
+}
+
+type programsPage struct {
+	Data []struct {
+		Attributes programAttributes `json:"attributes"`
+	} `json:"data"`
+	Links pageLinks `json:"links"`
+}
+
+// pageLinks son los enlaces de paginación de la API; Next falta en la última
+// página.
+type pageLinks struct {
+	Next string `json:"next"`
+}
+
+// nextPageURL resuelve links.next respecto a la página actual y devuelve ""
+// si no hay más páginas. Solo se siguen enlaces al mismo host, que son los
+// únicos a los que se pueden enviar las credenciales.
+func nextPageURL(current, next string) (string, error) {
+	if next == "" {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination link %q: %w", next, err)
+	}
+	if u.Scheme != base.Scheme || u.Host != base.Host {
+		return "", fmt.Errorf("pagination link %q points to another host", next)
+	}
+	return u.String(), nil
+}
+
+type programAttributes struct {
+	Handle         string    `json:"handle"`
+	OffersBounties bool      `json:"offers_bounties"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// Rango de la tabla de recompensas; nil si el programa no la publica.
+	MinimumBounty *float64 `json:"minimum_bounty_table_value"`
+	MaximumBounty *float64 `json:"maximum_bounty_table_value"`
+}
+
+type scopePage struct {
+	Data []struct {
+		Attributes struct {
+			EligibleForBounty     bool   `json:"eligible_for_bounty"`
+			EligibleForSubmission bool   `json:"eligible_for_submission"`
+			AssetIdentifier       string `json:"asset_identifier"`
+			AssetType             string `json:"asset_type"`
+			Instruction           string `json:"instruction"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Links pageLinks `json:"links"`
+}
+
+func (h Fetcher) Fetch(ctx context.Context, creds platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := h.Client
+	if client == nil {
+		// Cliente con timeout más generoso para evitar timeouts prematuros
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if h.Public {
+		if h.Handle != "" {
+			return 0, errors.New("-handle necesita credenciales de la API; no está disponible con el directorio público")
+		}
+		return h.fetchPublic(ctx, client, out)
+	}
+	if creds.Username == "" || creds.Token == "" {
+		return 0, fmt.Errorf("%w: HackerOne requiere username y apikey", platforms.ErrInvalidCredentials)
+	}
+	header := http.Header{"Authorization": {"Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Token))}}
+	processed := 0
+	pageSize := h.PageSize
+	if pageSize == 0 {
+		pageSize = MaxPageSize
+	}
+	if h.Handle != "" {
+		return h.fetchHandle(ctx, client, header, out)
+	}
+	var cutoff time.Time
+	if h.Since > 0 {
+		cutoff = time.Now().Add(-h.Since)
+	}
+
+	// La paginación sigue links.next: la última página no lo trae.
+	next := fmt.Sprintf("https://api.hackerone.com/v1/hackers/programs?page[number]=1&page[size]=%d", pageSize)
+	for page := 1; next != ""; page++ {
+		select {
+		case <-ctx.Done():
+			return processed, ctx.Err()
+		default:
+		}
+
+		// Red de seguridad contra una paginación que nunca termina.
+		if h.MaxPages > 0 && page > h.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas (-max-pages), se detiene la paginación", h.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		current := next
+		body, err := platforms.DoRequestWithRetry(ctx, client, current, header)
+		if err != nil {
+			return processed, fmt.Errorf("programs page request failed: %w", err)
+		}
+
+		var pg programsPage
+		if err := platforms.Unmarshal(body, &pg, h.Strict); err != nil {
+			return processed, err
+		}
+		if next, err = nextPageURL(current, pg.Links.Next); err != nil {
+			return processed, err
+		}
+
+		var raw []json.RawMessage
+		if h.Raw != nil {
+			raw = rawEntries(body)
+		}
+
+		var progs []programRef
+		for i, d := range pg.Data {
+			handle := d.Attributes.Handle
+			if reason := h.skipReason(d.Attributes, cutoff); reason != "" {
+				if h.Verbose {
+					log.Printf("se omite %s: %s", handle, reason)
+				}
+				continue
+			}
+			if h.Skip != nil && h.Skip(handle) {
+				continue
+			}
+			if i < len(raw) {
+				h.Raw.Save(handle, "program.json", raw[i])
+			}
+			progs = append(progs, programRef{handle: handle, offersBounties: d.Attributes.OffersBounties, updatedAt: d.Attributes.UpdatedAt})
+		}
+		written, err := h.writePrograms(ctx, client, header, progs, out)
+		processed += written
+		if err != nil {
+			return processed, err
+		}
+	}
+
+	return processed, nil
+}
+
+// writeProgram descarga el scope de handle y escribe sus activos en out y sus
+// exclusiones en h.Exclusions. Devuelve false sin error si el programa se
+// omitió por no estar disponible su scope.
+func (h Fetcher) writeProgram(ctx context.Context, client *http.Client, header http.Header, handle string, offersBounties bool, out platforms.AssetWriter) (bool, error) {
+	assets, excluded, err := h.cachedEligibleAssets(ctx, client, header, handle, time.Time{})
+	return h.writeScope(handle, offersBounties, assets, excluded, err, out)
+}
+
+// writeScope escribe el scope ya descargado de handle; err es el error de la
+// descarga.
+func (h Fetcher) writeScope(handle string, offersBounties bool, assets, excluded []platforms.Asset, err error, out platforms.AssetWriter) (bool, error) {
+	if err != nil {
+		// Un programa que pasó a privado entre el listado y la consulta
+		// de su scope responde 404/403: se omite salvo con -strict-handles.
+		if !h.StrictHandles && platforms.IsUnavailableScope(err) {
+			log.Printf("aviso: se omite %s: scope no disponible (%v)", handle, err)
+			return false, nil
+		}
+		// devolvemos error: usuario pidió que solo salga el error
+		return false, fmt.Errorf("handle %s failed: %w", handle, err)
+	}
+	assets = h.filterByInstruction(assets)
+	if h.Collapse {
+		assets = platforms.CollapseWildcards(assets)
+	}
+	p := platforms.Program{Platform: "hackerone", Handle: handle, OffersBounties: offersBounties, Assets: assets, OutOfScope: excluded}
+	if err := platforms.WriteProgram(out, h.Exclusions, p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// fetchHandle procesa solo el programa h.Handle, sin recorrer el listado ni
+// aplicar sus filtros (VDP, -since, -min-bounty): el usuario lo pidió
+// explícitamente. Se consulta el programa solo para saber si paga bounties.
+func (h Fetcher) fetchHandle(ctx context.Context, client *http.Client, header http.Header, out platforms.AssetWriter) (int, error) {
+	handle := h.Handle
+	endpoint := fmt.Sprintf("https://api.hackerone.com/v1/hackers/programs/%s", handle)
+	body, err := platforms.DoRequestWithRetry(ctx, client, endpoint, header)
+	if err != nil {
+		return 0, fmt.Errorf("program %s request failed: %w", handle, err)
+	}
+	// Decodificación laxa también con -strict: el objeto completo del programa
+	// trae muchos campos que aquí no interesan y harían fallar -strict.
+	var program struct {
+		Attributes struct {
+			OffersBounties bool `json:"offers_bounties"`
+		} `json:"attributes"`
+	}
+	if err := platforms.SafeUnmarshal(body, &program); err != nil {
+		return 0, err
+	}
+	h.Raw.Save(handle, "program.json", body)
+
+	platforms.Processing(handle)
+	// El programa se pidió explícitamente: un scope no disponible es un error.
+	h.StrictHandles = true
+	if _, err := h.writeProgram(ctx, client, header, handle, program.Attributes.OffersBounties, out); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// FetchStream emite los activos a medida que se descubren; ver platforms.FetchStream.
+func (h Fetcher) FetchStream(ctx context.Context, creds platforms.Credentials) (<-chan platforms.Asset, <-chan error) {
+	return platforms.FetchStream(ctx, h, creds)
+}
+
+// cachedEligibleAssets sirve el scope desde h.Scopes si ya se descargó (o se
+// está descargando) en esta ejecución, o desde h.Snapshot si el programa no
+// cambió desde updatedAt, y si no lo descarga y lo guarda.
+func (h Fetcher) cachedEligibleAssets(ctx context.Context, client *http.Client, header http.Header, handle string, updatedAt time.Time) (assets, excluded []platforms.Asset, err error) {
+	fetch := func() ([]platforms.Asset, []platforms.Asset, error) {
+		start := time.Now()
+		defer platforms.ObserveProgramFetch("hackerone", start)
+		return h.fetchEligibleAssets(ctx, client, header, handle)
+	}
+	return h.Scopes.Load(ctx, "hackerone", handle, func() ([]platforms.Asset, []platforms.Asset, error) {
+		// El directorio público no informa de updated_at ni ve el mismo scope.
+		if h.Public {
+			return fetch()
+		}
+		// El scope guardado ya está filtrado por elegibilidad.
+		return h.Snapshot.Load("hackerone/"+handle+"#"+h.Eligibility, updatedAt, fetch)
+	})
+}
+
+// fetchEligibleAssets devuelve los activos elegibles de un programa (según
+// -eligibility) y, por separado, los excluidos explícitamente del scope. Un
+// activo no elegible para bounty pero sí para reportes sigue en scope y no
+// cuenta como exclusión; solo eligible_for_submission=false marca un activo
+// fuera de scope.
+func (h Fetcher) fetchEligibleAssets(ctx context.Context, client *http.Client, header http.Header, handle string) (assets, excluded []platforms.Asset, err error) {
+	if h.Public {
+		return h.fetchPublicScope(ctx, client, handle)
+	}
+	// Los scopes también se paginan, igual que el listado de programas.
+	next := fmt.Sprintf("https://api.hackerone.com/v1/hackers/programs/%s/structured_scopes?page[number]=1&page[size]=%d", handle, MaxPageSize)
+	for page := 1; next != ""; page++ {
+		if h.MaxPages > 0 && page > h.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de scope en %s (-max-pages)", h.MaxPages, handle)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		current := next
+		body, err := platforms.DoRequestWithRetry(ctx, client, current, header)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var pg scopePage
+		if err := platforms.Unmarshal(body, &pg, h.Strict); err != nil {
+			return nil, nil, err
+		}
+		if next, err = nextPageURL(current, pg.Links.Next); err != nil {
+			return nil, nil, err
+		}
+		h.Raw.Save(handle, fmt.Sprintf("structured_scopes-%d.json", page), body)
+
+		for _, d := range pg.Data {
+			asset := platforms.Asset{
+				Platform:    "hackerone",
+				Identifier:  d.Attributes.AssetIdentifier,
+				Type:        d.Attributes.AssetType,
+				Instruction: d.Attributes.Instruction,
+			}
+			eligible := d.Attributes.EligibleForBounty
+			asset.EligibleForBounty = &eligible
+			switch {
+			case Eligible(h.Eligibility, d.Attributes.EligibleForBounty, d.Attributes.EligibleForSubmission):
+				assets = append(assets, asset)
+			case !d.Attributes.EligibleForSubmission:
+				excluded = append(excluded, asset)
+			}
+		}
+	}
+	return assets, excluded, nil
+}
+
+// filterByInstruction aplica -instruction-contains e -instruction-excludes:
+// conserva solo los activos cuya instrucción contiene el primero y descarta
+// los que contienen el segundo.
+func (h Fetcher) filterByInstruction(assets []platforms.Asset) []platforms.Asset {
+	if h.InstructionContains == "" && h.InstructionExcludes == "" {
+		return assets
+	}
+	contains := strings.ToLower(h.InstructionContains)
+	excludes := strings.ToLower(h.InstructionExcludes)
+
+	kept := assets[:0]
+	for _, a := range assets {
+		instr := strings.ToLower(a.Instruction)
+		if contains != "" && !strings.Contains(instr, contains) {
+			continue
+		}
+		if excludes != "" && strings.Contains(instr, excludes) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// skipReason decide si un programa del listado se omite y devuelve el motivo,
+// o "" si se procesa. Reúne todos los filtros por programa para que -verbose
+// pueda explicar por qué falta un programa en la salida.
+func (h Fetcher) skipReason(p programAttributes, cutoff time.Time) string {
+	if !p.OffersBounties && !h.IncludeVDP {
+		return "offers_bounties=false (usa -include-vdp)"
+	}
+	// Sin fecha de actualización el programa se incluye siempre.
+	if !cutoff.IsZero() && !p.UpdatedAt.IsZero() && p.UpdatedAt.Before(cutoff) {
+		return fmt.Sprintf("sin cambios desde %s (-since)", p.UpdatedAt.Format(time.RFC3339))
+	}
+	if !h.meetsMinBounty(p.MaximumBounty) {
+		if p.MaximumBounty == nil {
+			return "sin tabla de recompensas (-require-bounty-table)"
+		}
+		return fmt.Sprintf("recompensa máxima %g por debajo de -min-bounty %g", *p.MaximumBounty, h.MinBounty)
+	}
+	return ""
+}
+
+// meetsMinBounty indica si un programa con la recompensa máxima indicada pasa
+// el filtro -min-bounty.
+func (h Fetcher) meetsMinBounty(max *float64) bool {
+	if max == nil {
+		return !h.RequireBountyTable
+	}
+	return *max >= h.MinBounty
+}
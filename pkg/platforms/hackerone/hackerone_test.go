@@ -0,0 +1,75 @@
+package hackerone
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+)
+
+// rewriteTransport envía a target las solicitudes dirigidas a la API real,
+// conservando la ruta y la query.
+type rewriteTransport struct{ target *url.URL }
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetchEligibleAssetsFollowsScopePages(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"data":[
+			{"attributes":{"asset_identifier":"*.acme.com","asset_type":"WILDCARD","eligible_for_bounty":true,"eligible_for_submission":true}},
+			{"attributes":{"asset_identifier":"blog.acme.com","asset_type":"URL","eligible_for_bounty":false,"eligible_for_submission":false}}
+		],"links":{"next":"https://api.hackerone.com/v1/hackers/programs/acme/structured_scopes?page[number]=2&page[size]=100"}}`,
+		"2": `{"data":[
+			{"attributes":{"asset_identifier":"api.acme.com","asset_type":"URL","eligible_for_bounty":true,"eligible_for_submission":true}}
+		],"links":{}}`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/hackers/programs/acme/structured_scopes" {
+			http.NotFound(w, r)
+			return
+		}
+		page := r.URL.Query().Get("page[number]")
+		body, ok := pages[page]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		requested = append(requested, page)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	h := Fetcher{Eligibility: EligibilityBounty}
+	client := &http.Client{Transport: rewriteTransport{target}}
+	assets, excluded, err := h.fetchEligibleAssets(context.Background(), client, http.Header{}, "acme")
+	if err != nil {
+		t.Fatalf("fetchEligibleAssets: %v", err)
+	}
+
+	var got []string
+	for _, a := range assets {
+		got = append(got, a.Identifier)
+	}
+	sort.Strings(got)
+	want := []string{"*.acme.com", "api.acme.com"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("activos = %v, se esperaba %v", got, want)
+	}
+	if len(excluded) != 1 || excluded[0].Identifier != "blog.acme.com" {
+		t.Errorf("excluidos = %v, se esperaba blog.acme.com", excluded)
+	}
+	if fmt.Sprint(requested) != "[1 2]" {
+		t.Errorf("páginas pedidas = %v, se esperaba [1 2]", requested)
+	}
+}
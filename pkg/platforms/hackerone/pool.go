@@ -0,0 +1,79 @@
+package hackerone
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Descarga concurrente de scopes
+ *****************/
+
+// programRef es un programa del listado cuyo scope hay que descargar.
+type programRef struct {
+	handle         string
+	offersBounties bool
+	// updatedAt es la última actualización según el listado (cero si no la
+	// informa).
+	updatedAt time.Time
+}
+
+type scopeResult struct {
+	assets, excluded []platforms.Asset
+	err              error
+}
+
+// writePrograms descarga el scope de progs con hasta h.Concurrency
+// solicitudes a la vez y los escribe en out en el orden de progs, de modo que
+// la salida no depende de la concurrencia. Las pausas por límite de la API se
+// comparten entre todas las solicitudes. Ante el primer error se cancelan las
+// descargas pendientes.
+func (h Fetcher) writePrograms(ctx context.Context, client *http.Client, header http.Header, progs []programRef, out platforms.AssetWriter) (int, error) {
+	workers := h.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Cada resultado tiene su propio canal con hueco para un valor: los
+	// trabajadores nunca se bloquean aunque se deje de leer.
+	results := make([]chan scopeResult, len(progs))
+	for i := range results {
+		results[i] = make(chan scopeResult, 1)
+	}
+	go func() {
+		sem := make(chan struct{}, workers)
+		for i, p := range progs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] <- scopeResult{err: ctx.Err()}
+				continue
+			}
+			go func(i int, p programRef) {
+				defer func() { <-sem }()
+				var r scopeResult
+				r.assets, r.excluded, r.err = h.cachedEligibleAssets(ctx, client, header, p.handle, p.updatedAt)
+				results[i] <- r
+			}(i, p)
+		}
+	}()
+
+	processed := 0
+	for i, p := range progs {
+		r := <-results[i]
+		platforms.Processing(p.handle)
+		written, err := h.writeScope(p.handle, p.offersBounties, r.assets, r.excluded, r.err, out)
+		if err != nil {
+			return processed, err
+		}
+		if written {
+			processed++
+		}
+	}
+	return processed, nil
+}
@@ -0,0 +1,196 @@
+package hackerone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Directorio público de HackerOne (-public-only)
+ *****************/
+
+// graphQLURL es el endpoint GraphQL que usa la web pública de
+// HackerOne; no requiere autenticación para los programas públicos.
+const graphQLURL = "https://hackerone.com/graphql"
+
+const directoryQuery = `query Directory($cursor: String) {
+  teams(first: 100, after: $cursor, secure_order_by: {started_accepting_at: {_direction: DESC}}, where: {_and: [{_or: [{submission_state: {_eq: open}}, {submission_state: {_eq: api_only}}]}, {state: {_eq: public_mode}}]}) {
+    pageInfo { endCursor hasNextPage }
+    edges { node { handle offers_bounties } }
+  }
+}`
+
+const teamAssetsQuery = `query TeamAssets($handle: String!) {
+  team(handle: $handle) {
+    structured_scopes(first: 500, archived: false) {
+      edges { node { asset_identifier asset_type instruction eligible_for_bounty eligible_for_submission } }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	OperationName string                 `json:"operationName"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type directoryResponse struct {
+	Data struct {
+		Teams struct {
+			PageInfo struct {
+				EndCursor   string `json:"endCursor"`
+				HasNextPage bool   `json:"hasNextPage"`
+			} `json:"pageInfo"`
+			Edges []struct {
+				Node struct {
+					Handle         string `json:"handle"`
+					OffersBounties bool   `json:"offers_bounties"`
+				} `json:"node"`
+			} `json:"edges"`
+		} `json:"teams"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type teamAssetsResponse struct {
+	Data struct {
+		// Team es nil si el programa no existe o no es público.
+		Team *struct {
+			StructuredScopes struct {
+				Edges []struct {
+					Node struct {
+						AssetIdentifier       string `json:"asset_identifier"`
+						AssetType             string `json:"asset_type"`
+						Instruction           string `json:"instruction"`
+						EligibleForBounty     bool   `json:"eligible_for_bounty"`
+						EligibleForSubmission bool   `json:"eligible_for_submission"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"structured_scopes"`
+		} `json:"team"`
+	} `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+// fetchPublic recorre el directorio público de programas en lugar del
+// listado de la API. Los filtros y la salida son los mismos que con
+// credenciales, salvo -since y -min-bounty, que el directorio no informa.
+func (h Fetcher) fetchPublic(ctx context.Context, client *http.Client, out platforms.AssetWriter) (int, error) {
+	processed := 0
+	cursor := ""
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		if h.MaxPages > 0 && page > h.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas del directorio público (-max-pages)", h.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		vars := map[string]interface{}{}
+		if cursor != "" {
+			vars["cursor"] = cursor
+		}
+		var resp directoryResponse
+		if err := h.graphQL(ctx, client, "Directory", directoryQuery, vars, &resp, &resp.Errors); err != nil {
+			return processed, fmt.Errorf("public directory request failed: %w", err)
+		}
+
+		var progs []programRef
+		for _, e := range resp.Data.Teams.Edges {
+			handle := e.Node.Handle
+			attrs := programAttributes{Handle: handle, OffersBounties: e.Node.OffersBounties}
+			if reason := h.skipReason(attrs, time.Time{}); reason != "" {
+				if h.Verbose {
+					log.Printf("se omite %s: %s", handle, reason)
+				}
+				continue
+			}
+			if h.Skip != nil && h.Skip(handle) {
+				continue
+			}
+			progs = append(progs, programRef{handle: handle, offersBounties: e.Node.OffersBounties})
+		}
+		written, err := h.writePrograms(ctx, client, nil, progs, out)
+		processed += written
+		if err != nil {
+			return processed, err
+		}
+
+		if !resp.Data.Teams.PageInfo.HasNextPage || resp.Data.Teams.PageInfo.EndCursor == "" {
+			break
+		}
+		cursor = resp.Data.Teams.PageInfo.EndCursor
+	}
+	return processed, nil
+}
+
+// fetchPublicScope es el equivalente de fetchEligibleAssets para el modo
+// público: obtiene el scope por GraphQL y lo clasifica igual.
+func (h Fetcher) fetchPublicScope(ctx context.Context, client *http.Client, handle string) (assets, excluded []platforms.Asset, err error) {
+	var resp teamAssetsResponse
+	if err := h.graphQL(ctx, client, "TeamAssets", teamAssetsQuery, map[string]interface{}{"handle": handle}, &resp, &resp.Errors); err != nil {
+		return nil, nil, err
+	}
+	if resp.Data.Team == nil {
+		// Igual que un 404 de la API: el programa se omite salvo con
+		// -strict-handles.
+		return nil, nil, &platforms.APIError{StatusCode: http.StatusNotFound, Status: "404 Not Found (programa no público)"}
+	}
+
+	for _, e := range resp.Data.Team.StructuredScopes.Edges {
+		n := e.Node
+		asset := platforms.Asset{
+			Platform:    "hackerone",
+			Identifier:  n.AssetIdentifier,
+			Type:        n.AssetType,
+			Instruction: n.Instruction,
+		}
+		eligible := n.EligibleForBounty
+		asset.EligibleForBounty = &eligible
+		switch {
+		case Eligible(h.Eligibility, n.EligibleForBounty, n.EligibleForSubmission):
+			assets = append(assets, asset)
+		case !n.EligibleForSubmission:
+			excluded = append(excluded, asset)
+		}
+	}
+	return assets, excluded, nil
+}
+
+// graphQL envía una consulta al endpoint público y decodifica la respuesta en
+// v; errs debe apuntar al campo de errores de v para convertirlos en error.
+func (h Fetcher) graphQL(ctx context.Context, client *http.Client, operation, query string, vars map[string]interface{}, v interface{}, errs *[]graphQLError) error {
+	payload, err := json.Marshal(graphQLRequest{OperationName: operation, Query: query, Variables: vars})
+	if err != nil {
+		return err
+	}
+	body, err := platforms.DoPostWithRetry(ctx, client, graphQLURL, nil, payload)
+	if err != nil {
+		return err
+	}
+	if err := platforms.Unmarshal(body, v, h.Strict); err != nil {
+		return err
+	}
+	if len(*errs) > 0 {
+		msgs := make([]string, len(*errs))
+		for i, e := range *errs {
+			msgs[i] = e.Message
+		}
+		return errors.New("graphql: " + strings.Join(msgs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package hackerone
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Exportación de JSON crudo (-raw-dir)
+ *****************/
+
+// RawStore guarda las respuestas de la API tal cual llegaron, un directorio
+// por programa: program.json con su entrada del listado y
+// structured_scopes-<n>.json con cada página de scope. Es independiente de la
+// salida de activos; un valor nil no guarda nada.
+type RawStore struct{ dir string }
+
+func NewRawStore(dir string) (*RawStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+	}
+	return &RawStore{dir: dir}, nil
+}
+
+// Save escribe data en <dir>/<handle>/<name>. Los fallos solo se avisan: no
+// deben interrumpir la descarga del scope.
+func (r *RawStore) Save(handle, name string, data []byte) {
+	if r == nil {
+		return
+	}
+	dir := filepath.Join(r.dir, platforms.SafeFileName(handle))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Printf("aviso: no se pudo crear %s: %v", dir, err)
+		return
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("aviso: no se pudo guardar %s: %v", path, err)
+	}
+}
+
+// rawEntries devuelve las entradas de data de una página de la API sin
+// decodificar, en el mismo orden que al decodificarla con tipos.
+func rawEntries(body []byte) []json.RawMessage {
+	var pg struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &pg); err != nil {
+		return nil
+	}
+	return pg.Data
+}
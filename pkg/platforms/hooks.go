@@ -0,0 +1,76 @@
+package platforms
+
+import (
+	"context"
+	"time"
+)
+
+// Hooks deja que el programa que usa el paquete observe la actividad de los
+// fetchers (métricas, estadísticas, progreso) sin que estos dependan de él.
+// Los campos nil se ignoran; se configuran antes de lanzar los fetchers.
+var Hooks struct {
+	// Request se llama antes de enviar cada solicitud HTTP.
+	Request func(ctx context.Context)
+	// Error se llama por cada solicitud fallida; status es 0 si no hubo
+	// respuesta (fallo de red).
+	Error func(ctx context.Context, status int)
+	// Retry se llama antes de cada reintento.
+	Retry func()
+	// ProgramFetched recibe lo que tardó la descarga del scope de un programa.
+	ProgramFetched func(platform string, d time.Duration)
+	// Processing se llama al empezar a escribir cada programa.
+	Processing func(handle string)
+	// Program recibe cada programa que un fetcher escribe, tenga o no activos
+	// en scope.
+	Program func(p Program)
+	// PageLimit se llama cuando una paginación se corta en MaxPages: lo que
+	// quedaba por descargar no se vio, y no debe tomarse por eliminado.
+	PageLimit func(ctx context.Context)
+}
+
+// ObserveProgramFetch informa a Hooks.ProgramFetched de la descarga del scope
+// de un programa que empezó en start.
+func ObserveProgramFetch(platform string, start time.Time) {
+	if Hooks.ProgramFetched != nil {
+		Hooks.ProgramFetched(platform, time.Since(start))
+	}
+}
+
+// Processing informa a Hooks.Processing del programa que se va a escribir.
+func Processing(handle string) {
+	if Hooks.Processing != nil {
+		Hooks.Processing(handle)
+	}
+}
+
+func hookProgram(p Program) {
+	if Hooks.Program != nil {
+		Hooks.Program(p)
+	}
+}
+
+// PageLimitReached informa a Hooks.PageLimit de que el fetcher dejó de
+// paginar por MaxPages.
+func PageLimitReached(ctx context.Context) {
+	if Hooks.PageLimit != nil {
+		Hooks.PageLimit(ctx)
+	}
+}
+
+func hookRequest(ctx context.Context) {
+	if Hooks.Request != nil {
+		Hooks.Request(ctx)
+	}
+}
+
+func hookError(ctx context.Context, status int) {
+	if Hooks.Error != nil {
+		Hooks.Error(ctx, status)
+	}
+}
+
+func hookRetry() {
+	if Hooks.Retry != nil {
+		Hooks.Retry()
+	}
+}
@@ -0,0 +1,290 @@
+package platforms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*****************
+ * Solicitudes HTTP
+ *****************/
+
+// APIError es una respuesta de error (4xx/5xx) de la API de una plataforma.
+type APIError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter es la espera que pide la cabecera Retry-After (0 si no la
+	// trae).
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode >= 500 {
+		return "API unavailable: " + e.Status
+	}
+	return "API returned error " + e.Status
+}
+
+// IsUnavailableScope indica si err es un 404/403 de la API, es decir, un
+// programa cuyo scope ya no es accesible.
+func IsUnavailableScope(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// ErrNoRetryBudget indica que se renunció a un reintento porque el timeout
+// total vencería durante la espera.
+var ErrNoRetryBudget = errors.New("sin tiempo para reintentar antes del timeout total")
+
+// Retry configura los reintentos de DoRequestWithRetry, DoPostWithRetry y
+// DoPageRequestWithRetry. Se ajusta antes de empezar a hacer solicitudes.
+var Retry = struct {
+	// MaxRetries es el número de reintentos tras el primer intento.
+	MaxRetries int
+	// BaseDelay es la espera antes del primer reintento; se duplica en cada
+	// uno y se le aplica un jitter de ±50 %.
+	BaseDelay time.Duration
+}{MaxRetries: 2, BaseDelay: 2 * time.Second}
+
+// maxRetryAfter acota la espera que puede pedir una cabecera Retry-After.
+const maxRetryAfter = 5 * time.Minute
+
+// DoRequestWithRetry reintenta la solicitud ante timeouts, 429 y 5xx según
+// Retry.
+func DoRequestWithRetry(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	return withRetry(ctx, func() ([]byte, error) {
+		return DoRequest(ctx, client, url, header)
+	})
+}
+
+// DoPostWithRetry envía payload por POST (p. ej. una consulta GraphQL) con la
+// misma política de reintentos que DoRequestWithRetry.
+func DoPostWithRetry(ctx context.Context, client *http.Client, url string, header http.Header, payload []byte) ([]byte, error) {
+	return withRetry(ctx, func() ([]byte, error) {
+		return SendRequest(ctx, client, http.MethodPost, url, header, payload)
+	})
+}
+
+// DoPageRequestWithRetry descarga una página HTML (para las plataformas sin
+// API) con la política de reintentos de DoRequestWithRetry. Solo rechaza las
+// respuestas vacías.
+func DoPageRequestWithRetry(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	header := http.Header{"Accept": {"text/html"}}
+	return withRetry(ctx, func() ([]byte, error) {
+		body, _, err := sendRawRequest(ctx, client, http.MethodGet, url, header, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil, errors.New("unexpected empty response body")
+		}
+		return body, nil
+	})
+}
+
+// withRetry ejecuta do y lo reintenta hasta Retry.MaxRetries veces mientras
+// falle por timeout, por un 429 o por un 5xx. La espera es exponencial con
+// jitter, salvo que la respuesta indique Retry-After.
+func withRetry(ctx context.Context, do func() ([]byte, error)) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= Retry.MaxRetries; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay(attempt, lastErr)
+			// Si el timeout total vence antes de terminar la espera, el
+			// reintento está condenado: se devuelve el error sin dormir.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+				return nil, fmt.Errorf("%w: %w", ErrNoRetryBudget, lastErr)
+			}
+			var apiErr *APIError
+			if errors.As(lastErr, &apiErr) {
+				log.Printf("aviso: %v; reintento %d/%d en %s", lastErr, attempt, Retry.MaxRetries, delay.Round(time.Millisecond))
+			}
+			hookRetry()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		body, err := do()
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+
+		if !retryable(err) {
+			return nil, err
+		}
+	}
+	if Retry.MaxRetries == 0 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("después de %d intentos: %w", Retry.MaxRetries+1, lastErr)
+}
+
+// retryable indica si merece la pena repetir una solicitud que falló con err:
+// timeouts, 429 Too Many Requests y errores del servidor.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return strings.Contains(err.Error(), "deadline exceeded")
+}
+
+// retryDelay es la espera antes del reintento número attempt (desde 1):
+// Retry-After si la respuesta lo indicó o, si no, Retry.BaseDelay·2^(attempt-1)
+// con un jitter de ±50 % para que las solicitudes concurrentes no se
+// reintenten todas a la vez.
+func retryDelay(attempt int, lastErr error) time.Duration {
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, maxRetryAfter)
+	}
+	delay := Retry.BaseDelay << uint(attempt-1)
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay)))
+}
+
+// parseRetryAfter interpreta Retry-After, que puede ser un número de segundos
+// o una fecha HTTP.
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// DoRequest centraliza la lógica HTTP con manejo de errores, timeout y códigos de estado.
+func DoRequest(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	return SendRequest(ctx, client, http.MethodGet, url, header, nil)
+}
+
+// SendRequest hace una solicitud con el método indicado; payload, si no es
+// nil, se envía como cuerpo JSON.
+func SendRequest(ctx context.Context, client *http.Client, method, url string, header http.Header, payload []byte) ([]byte, error) {
+	body, contentType, err := sendRawRequest(ctx, client, method, url, header, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkJSONResponse(contentType, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// sendRawRequest hace la solicitud y devuelve el cuerpo y su Content-Type sin
+// comprobar el formato.
+func sendRawRequest(ctx context.Context, client *http.Client, method, url string, header http.Header, payload []byte) ([]byte, string, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	// header aporta la autenticación de la plataforma y puede sustituir los
+	// valores por defecto (p. ej. el Accept de Bugcrowd).
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if err := waitToken(ctx, req.URL.Host); err != nil {
+		return nil, "", err
+	}
+	if err := apiRateLimit.wait(ctx, req.URL.Host); err != nil {
+		return nil, "", err
+	}
+
+	hookRequest(ctx)
+	resp, err := client.Do(req)
+	if err != nil {
+		hookError(ctx, 0)
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	apiRateLimit.update(req.URL.Host, resp.Header)
+
+	if resp.StatusCode >= 400 {
+		hookError(ctx, resp.StatusCode)
+		return nil, "", &APIError{StatusCode: resp.StatusCode, Status: resp.Status, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// checkJSONResponse rechaza las respuestas que no son JSON, como la página
+// HTML de un portal cautivo, un proxy que intercepta o una redirección a la
+// pantalla de login, que de otro modo acabarían en una salida vacía sin
+// explicación. Sin Content-Type se acepta cualquier cuerpo no vacío.
+func checkJSONResponse(contentType string, body []byte) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return errors.New("unexpected empty response body")
+	}
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	// Los archivos JSON de raw.githubusercontent.com llegan como text/plain.
+	if mediaType == "text/plain" && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return nil
+	}
+	snippet := trimmed
+	if len(snippet) > 200 {
+		snippet = snippet[:200]
+	}
+	return fmt.Errorf("unexpected response content type %q (expected JSON): %s", contentType, snippet)
+}
+
+// SafeUnmarshal incluye recuperación de panic por JSON inválido.
+func SafeUnmarshal(data []byte, v interface{}) error {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = errors.New("panic")
+			}
+			log.Printf("panic recuperado: %v", err)
+		}
+	}()
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("JSON decode failed: %w", err)
+	}
+	return nil
+}
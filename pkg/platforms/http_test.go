@@ -0,0 +1,17 @@
+package platforms
+
+import "testing"
+
+func TestCheckJSONResponseTextPlain(t *testing.T) {
+	for _, body := range []string{`{"a":1}`, "\n  [1]\n", " {}"} {
+		if err := checkJSONResponse("text/plain; charset=utf-8", []byte(body)); err != nil {
+			t.Errorf("text/plain %q: %v", body, err)
+		}
+	}
+	if err := checkJSONResponse("text/plain", []byte("\n<html>")); err == nil {
+		t.Error("text/plain con HTML aceptado como JSON")
+	}
+	if err := checkJSONResponse("text/html", []byte(` {"a":1}`)); err == nil {
+		t.Error("text/html aceptado como JSON")
+	}
+}
@@ -0,0 +1,109 @@
+// Package immunefi descarga los proyectos públicos de Immunefi.
+package immunefi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Immunefi
+ *****************/
+
+// bountiesURL es el índice público con todos los proyectos de
+// Immunefi y sus activos en una sola respuesta.
+const bountiesURL = "https://immunefi.com/public-api/bounties.json"
+
+// Fetcher descarga los proyectos públicos de Immunefi. No necesita
+// credenciales ni pagina: el índice llega completo.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+}
+
+type project struct {
+	Slug      string  `json:"slug"`
+	Project   string  `json:"project"`
+	MaxBounty float64 `json:"maxBounty"`
+	Assets    []struct {
+		Type        string `json:"type"`
+		URL         string `json:"url"`
+		Description string `json:"description"`
+	} `json:"assets"`
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	start := time.Now()
+	body, err := platforms.DoRequestWithRetry(ctx, client, bountiesURL, nil)
+	platforms.ObserveProgramFetch("immunefi", start)
+	if err != nil {
+		return 0, fmt.Errorf("bounties index request failed: %w", err)
+	}
+	var projects []project
+	if err := platforms.Unmarshal(body, &projects, f.Strict); err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, p := range projects {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		handle := p.Slug
+		if handle == "" {
+			log.Printf("aviso: se omite un proyecto de Immunefi sin slug (%q)", p.Project)
+			continue
+		}
+		platforms.Processing(handle)
+
+		assets := make([]platforms.Asset, 0, len(p.Assets))
+		for _, a := range p.Assets {
+			assets = append(assets, platforms.Asset{
+				Platform:    "immunefi",
+				Identifier:  a.URL,
+				Type:        assetType(a.Type, a.URL),
+				Instruction: a.Description,
+			})
+		}
+		// Todos los proyectos de Immunefi pagan recompensas.
+		if err := platforms.WriteProgram(out, nil, platforms.Program{Platform: "immunefi", Handle: handle, OffersBounties: true, Assets: assets}); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// assetType etiqueta los activos de Immunefi: contratos, webs y
+// repositorios de código se distinguen para poder filtrarlos aguas abajo.
+func assetType(t, url string) string {
+	switch t {
+	case "smart_contract":
+		return platforms.AssetTypeSmartContract
+	case "websites_and_applications":
+		if strings.HasPrefix(url, "*.") {
+			return platforms.AssetTypeWildcard
+		}
+		return platforms.AssetTypeURL
+	case "blockchain_dlt":
+		return "BLOCKCHAIN"
+	}
+	if strings.Contains(url, "github.com/") || strings.Contains(url, "gitlab.com/") {
+		return "SOURCE_CODE"
+	}
+	return "OTHER"
+}
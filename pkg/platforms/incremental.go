@@ -0,0 +1,142 @@
+package platforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/*****************
+ * Scopes entre ejecuciones (descarga incremental)
+ *****************/
+
+// ScopeSnapshot guarda en disco el scope de cada programa junto con el momento
+// en que se descargó, para que la siguiente ejecución solo vuelva a descargar
+// los programas actualizados desde entonces. Sirve a las plataformas cuyo
+// listado informa de la fecha de actualización de cada programa. Es segura
+// para uso concurrente; un valor nil desactiva la reutilización.
+type ScopeSnapshot struct {
+	mu       sync.Mutex
+	path     string
+	previous map[string]snapshotEntry
+	current  map[string]snapshotEntry
+	reused   int
+}
+
+type snapshotEntry struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Assets    []Asset   `json:"assets"`
+	Excluded  []Asset   `json:"excluded,omitempty"`
+}
+
+// LoadScopeSnapshot lee los scopes guardados en path; si el archivo no existe
+// todos los programas se descargarán.
+func LoadScopeSnapshot(path string) (*ScopeSnapshot, error) {
+	s := &ScopeSnapshot{path: path, previous: make(map[string]snapshotEntry), current: make(map[string]snapshotEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.previous); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Load devuelve el scope guardado bajo key si se descargó después de
+// updatedAt y, si no, lo obtiene con fetch y lo guarda. Un updatedAt cero
+// (la plataforma no lo informa) obliga a descargarlo. Los errores no se
+// guardan.
+func (s *ScopeSnapshot) Load(key string, updatedAt time.Time, fetch func() (assets, excluded []Asset, err error)) ([]Asset, []Asset, error) {
+	if s == nil {
+		return fetch()
+	}
+	s.mu.Lock()
+	e, ok := s.previous[key]
+	if ok && !updatedAt.IsZero() && updatedAt.Before(e.FetchedAt) {
+		s.current[key] = e
+		s.reused++
+		s.mu.Unlock()
+		return append([]Asset(nil), e.Assets...), append([]Asset(nil), e.Excluded...), nil
+	}
+	s.mu.Unlock()
+
+	// Se toma la hora antes de descargar: un cambio durante la descarga
+	// obliga a repetirla la próxima vez.
+	start := time.Now().UTC()
+	assets, excluded, err := fetch()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	s.current[key] = snapshotEntry{
+		FetchedAt: start,
+		Assets:    append([]Asset(nil), assets...),
+		Excluded:  append([]Asset(nil), excluded...),
+	}
+	s.mu.Unlock()
+	return assets, excluded, nil
+}
+
+// Reused devuelve cuántos scopes se sirvieron sin descargarlos.
+func (s *ScopeSnapshot) Reused() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reused
+}
+
+// Save escribe los scopes de esta ejecución de forma atómica. Con prune se
+// descartan los que no se usaron (programas que ya no aparecen en el
+// listado); sin él, p. ej. tras una ejecución incompleta, se conservan.
+func (s *ScopeSnapshot) Save(prune bool) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	entries := make(map[string]snapshotEntry, len(s.current))
+	if !prune {
+		for k, e := range s.previous {
+			entries[k] = e
+		}
+	}
+	for k, e := range s.current {
+		entries[k] = e
+	}
+	s.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".sabb-scopes-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
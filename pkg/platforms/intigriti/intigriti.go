@@ -0,0 +1,196 @@
+// Package intigriti descarga el scope de los programas de Intigriti con la API
+// de investigadores.
+package intigriti
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Intigriti
+ *****************/
+
+// pageLimit es el tamaño de página del listado de programas.
+const pageLimit = 100
+
+// Niveles de dominio de Intigriti que no son elegibles para recompensa.
+const (
+	tierOutOfScope = "out of scope"
+	tierNoBounty   = "no bounty"
+)
+
+// Fetcher descarga el scope de los programas de Intigriti con la
+// API de investigadores y un token de acceso personal.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe los dominios fuera de scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+	// MaxPages limita las páginas del listado de programas (0 = sin límite).
+	MaxPages int
+}
+
+type programsPage struct {
+	MaxCount int `json:"maxCount"`
+	Records  []struct {
+		ID     string `json:"id"`
+		Handle string `json:"handle"`
+		Name   string `json:"name"`
+		// MaxBounty es nil o 0 en los programas sin recompensas.
+		MaxBounty *struct {
+			Value    float64 `json:"value"`
+			Currency string  `json:"currency"`
+		} `json:"maxBounty"`
+	} `json:"records"`
+}
+
+type programDetail struct {
+	Domains struct {
+		Content []struct {
+			Endpoint string `json:"endpoint"`
+			Type     struct {
+				Value string `json:"value"`
+			} `json:"type"`
+			Tier struct {
+				Value string `json:"value"`
+			} `json:"tier"`
+			Description string `json:"description"`
+		} `json:"content"`
+	} `json:"domains"`
+}
+
+func (f Fetcher) Fetch(ctx context.Context, creds platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if creds.Token == "" {
+		return 0, fmt.Errorf("%w: Intigriti requiere un token de acceso personal", platforms.ErrInvalidCredentials)
+	}
+	header := http.Header{"Authorization": {"Bearer " + creds.Token}}
+
+	processed := 0
+	for page := 0; ; page++ {
+		select {
+		case <-ctx.Done():
+			return processed, ctx.Err()
+		default:
+		}
+		if f.MaxPages > 0 && page >= f.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de programas de Intigriti (-max-pages)", f.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		offset := page * pageLimit
+		url := fmt.Sprintf("https://api.intigriti.com/external/researcher/v1/programs?limit=%d&offset=%d", pageLimit, offset)
+		body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+		if err != nil {
+			return processed, fmt.Errorf("programs page request failed: %w", err)
+		}
+		var pg programsPage
+		if err := platforms.Unmarshal(body, &pg, f.Strict); err != nil {
+			return processed, err
+		}
+		if len(pg.Records) == 0 {
+			break
+		}
+
+		for _, p := range pg.Records {
+			offersBounties := p.MaxBounty != nil && p.MaxBounty.Value > 0
+			if !offersBounties && !f.IncludeVDP {
+				continue
+			}
+			handle := p.Handle
+			platforms.Processing(handle)
+
+			start := time.Now()
+			assets, excluded, err := f.fetchDomains(ctx, client, header, p.ID, !offersBounties)
+			platforms.ObserveProgramFetch("intigriti", start)
+			if err != nil {
+				if platforms.IsUnavailableScope(err) {
+					log.Printf("aviso: se omite %s: scope no disponible (%v)", handle, err)
+					continue
+				}
+				return processed, fmt.Errorf("program %s failed: %w", handle, err)
+			}
+			if err := platforms.WriteProgram(out, f.Exclusions, platforms.Program{Platform: "intigriti", Handle: handle, OffersBounties: offersBounties, Assets: assets, OutOfScope: excluded}); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+
+		if pg.MaxCount > 0 && offset+len(pg.Records) >= pg.MaxCount {
+			break
+		}
+	}
+	return processed, nil
+}
+
+// fetchDomains devuelve los dominios elegibles para recompensa de un programa
+// y, por separado, los marcados fuera de scope. Los de nivel "No Bounty" se
+// pueden reportar pero no se pagan: solo se incluyen con noBounty, que se usa
+// para los programas sin recompensas (-include-vdp).
+func (f Fetcher) fetchDomains(ctx context.Context, client *http.Client, header http.Header, programID string, noBounty bool) (assets, excluded []platforms.Asset, err error) {
+	url := fmt.Sprintf("https://api.intigriti.com/external/researcher/v1/programs/%s", programID)
+	body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	var detail programDetail
+	if err := platforms.Unmarshal(body, &detail, f.Strict); err != nil {
+		return nil, nil, err
+	}
+
+	for _, d := range detail.Domains.Content {
+		asset := platforms.Asset{
+			Platform:    "intigriti",
+			Identifier:  d.Endpoint,
+			Type:        AssetType(d.Type.Value),
+			Instruction: d.Description,
+		}
+		switch strings.ToLower(d.Tier.Value) {
+		case tierOutOfScope:
+			excluded = append(excluded, asset)
+		case tierNoBounty:
+			if noBounty {
+				assets = append(assets, asset)
+			}
+		default:
+			assets = append(assets, asset)
+		}
+	}
+	return assets, excluded, nil
+}
+
+// AssetType traduce el tipo de dominio de Intigriti a los tipos de
+// activo de HackerOne que usa el resto de la herramienta.
+func AssetType(t string) string {
+	switch strings.ToLower(t) {
+	case "url":
+		return platforms.AssetTypeURL
+	case "wildcard":
+		return platforms.AssetTypeWildcard
+	case "iprange":
+		return platforms.AssetTypeCIDR
+	case "ip":
+		return platforms.AssetTypeIPAddress
+	case "android":
+		return "GOOGLE_PLAY_APP_ID"
+	case "ios":
+		return "APPLE_STORE_APP_ID"
+	}
+	return "OTHER"
+}
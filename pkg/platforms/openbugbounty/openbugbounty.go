@@ -0,0 +1,88 @@
+// Package openbugbounty lee el índice público de programas de Open Bug Bounty.
+package openbugbounty
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Open Bug Bounty
+ *****************/
+
+// listURL es el índice público de programas de Open Bug Bounty.
+// La plataforma no tiene API: el índice se lee del HTML.
+const listURL = "https://www.openbugbounty.org/bugbounty-list/"
+
+// programRe captura el handle y el dominio de cada fila del
+// índice: <a href="/bugbounty/acme/">acme.com</a>.
+var programRe = regexp.MustCompile(`href="/bugbounty/([^/"]+)/"[^>]*>\s*([^<\s]+)\s*</a>`)
+
+// Fetcher emite el dominio de cada programa de Open Bug Bounty.
+// Todos son de divulgación coordinada: elegir la plataforma ya implica
+// quererlos, así que -include-vdp no se aplica.
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// MaxPages limita las páginas del índice (0 = sin límite).
+	MaxPages int
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	seen := make(map[string]bool)
+	processed := 0
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return processed, err
+		}
+		if f.MaxPages > 0 && page > f.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de Open Bug Bounty (-max-pages)", f.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		start := time.Now()
+		body, err := platforms.DoPageRequestWithRetry(ctx, client, fmt.Sprintf("%s?page=%d", listURL, page))
+		platforms.ObserveProgramFetch("openbugbounty", start)
+		if err != nil {
+			return processed, fmt.Errorf("programs page request failed: %w", err)
+		}
+
+		// El índice no informa del número de páginas: se termina en la
+		// primera que no aporta programas nuevos.
+		found := 0
+		for _, m := range programRe.FindAllSubmatch(body, -1) {
+			handle := html.UnescapeString(string(m[1]))
+			if seen[handle] {
+				continue
+			}
+			seen[handle] = true
+			found++
+
+			domain := strings.ToLower(html.UnescapeString(string(m[2])))
+			platforms.Processing(handle)
+			asset := platforms.Asset{Platform: "openbugbounty", Identifier: domain, Type: platforms.AssetTypeURL}
+			if err := platforms.WriteProgram(out, nil, platforms.Program{Platform: "openbugbounty", Handle: handle, Assets: []platforms.Asset{asset}}); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+		if found == 0 {
+			break
+		}
+	}
+	return processed, nil
+}
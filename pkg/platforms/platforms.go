@@ -0,0 +1,227 @@
+// Package platforms define el modelo común de los fetchers de sabb (activos,
+// programas, credenciales) y la maquinaria HTTP que comparten: reintentos,
+// límite de peticiones y decodificación. Cada plataforma vive en su propio
+// subpaquete (platforms/hackerone, platforms/bugcrowd...).
+package platforms
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// ErrInvalidCredentials indica credenciales ausentes o mal formadas. Es un
+// error de uso, no de ejecución: los llamadores pueden detectarlo con
+// errors.Is para mostrar una ayuda en lugar de un fallo genérico.
+var ErrInvalidCredentials = errors.New("credenciales inválidas")
+
+// Credentials agrupa las credenciales de una plataforma. Cada fetcher lee los
+// campos que necesita: HackerOne usa Username y Token, el resto solo Token.
+// Extra admite datos adicionales específicos de una plataforma.
+type Credentials struct {
+	Username string
+	Token    string
+	Extra    map[string]string
+}
+
+// Fetcher define una interfaz común para las plataformas.
+// Retorna el número de programas procesados y un error en caso de fallo.
+type Fetcher interface {
+	Fetch(ctx context.Context, creds Credentials, out AssetWriter) (int, error)
+}
+
+// AssetWriter recibe los activos que emiten los fetchers.
+type AssetWriter interface {
+	WriteAsset(Asset) error
+}
+
+// AssetWriterFunc adapta una función a AssetWriter.
+type AssetWriterFunc func(Asset) error
+
+func (f AssetWriterFunc) WriteAsset(a Asset) error { return f(a) }
+
+// Asset representa un activo del scope junto con el tipo declarado por la plataforma.
+// OffersBounties refleja si el programa al que pertenece paga recompensas, de
+// modo que la salida estructurada pueda distinguir los programas VDP.
+type Asset struct {
+	Platform       string `json:"platform"`
+	Handle         string `json:"handle"`
+	Identifier     string `json:"asset"`
+	Type           string `json:"asset_type"`
+	OffersBounties bool   `json:"offers_bounties"`
+	Instruction    string `json:"instruction,omitempty"`
+	// EligibleForBounty es la elegibilidad del propio activo cuando la
+	// plataforma la informa (HackerOne); si es nil vale OffersBounties.
+	EligibleForBounty *bool `json:"eligible_for_bounty,omitempty"`
+}
+
+// Program es un programa con su scope normalizado, igual para todas las
+// plataformas: los activos en scope y los declarados fuera de scope. Los
+// fetchers rellenan Platform aunque el programa no tenga activos.
+type Program struct {
+	Platform       string
+	Handle         string
+	OffersBounties bool
+	Assets         []Asset
+	OutOfScope     []Asset
+}
+
+// BountyEligible indica si el activo da derecho a recompensa.
+func (a Asset) BountyEligible() bool {
+	if a.EligibleForBounty != nil {
+		return *a.EligibleForBounty
+	}
+	return a.OffersBounties
+}
+
+// Tipos de activo de HackerOne con tratamiento específico (colapsado de
+// wildcards, formato urls) o a los que se traducen los de otras plataformas.
+const (
+	AssetTypeURL           = "URL"
+	AssetTypeWildcard      = "WILDCARD"
+	AssetTypeCIDR          = "CIDR"
+	AssetTypeIPAddress     = "IP_ADDRESS"
+	AssetTypeSmartContract = "SMART_CONTRACT"
+)
+
+// WriteProgram escribe los activos en scope de p en out, con su handle y si
+// paga recompensas, y su out_of_scope en exclusions (si no es nil). Es el
+// final común de todos los fetchers, y el que informa a Hooks.Program.
+func WriteProgram(out, exclusions AssetWriter, p Program) error {
+	hookProgram(p)
+	for _, asset := range p.Assets {
+		asset.Handle = p.Handle
+		asset.OffersBounties = p.OffersBounties
+		if err := out.WriteAsset(asset); err != nil {
+			return err
+		}
+	}
+	if exclusions != nil {
+		for _, asset := range p.OutOfScope {
+			asset.Handle = p.Handle
+			asset.OffersBounties = p.OffersBounties
+			if err := exclusions.WriteAsset(asset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FetchStream ejecuta f en segundo plano y emite sus activos por un canal a
+// medida que se descubren, de modo que el llamador (escritores, filtros,
+// notificadores) puede procesar el primer programa antes de que termine el
+// último sin acumular el scope completo. Sirve para cualquier plataforma.
+// Ambos canales se cierran al terminar y errc recibe como mucho un error. El
+// llamador debe consumir assets hasta su cierre o cancelar ctx.
+func FetchStream(ctx context.Context, f Fetcher, creds Credentials) (<-chan Asset, <-chan error) {
+	assets := make(chan Asset)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(assets)
+		_, err := f.Fetch(ctx, creds, AssetWriterFunc(func(a Asset) error {
+			select {
+			case assets <- a:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}))
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return assets, errc
+}
+
+// GuessAssetType deduce el tipo de activo de un identificador escrito a mano.
+func GuessAssetType(id string) string {
+	switch {
+	case strings.HasPrefix(id, "*."):
+		return AssetTypeWildcard
+	case net.ParseIP(id) != nil:
+		return AssetTypeIPAddress
+	}
+	if _, _, err := net.ParseCIDR(id); err == nil {
+		return AssetTypeCIDR
+	}
+	return AssetTypeURL
+}
+
+// CollapseWildcards elimina los activos de un programa que ya están cubiertos
+// por un wildcard del mismo programa: api.example.com o *.api.example.com son
+// redundantes si existe *.example.com. Solo se colapsan hostnames sin ruta,
+// puerto ni query, que son los que el wildcard cubre de forma equivalente; el
+// dominio raíz (example.com) tampoco se colapsa. Las exclusiones explícitas
+// (!host) no se tienen en cuenta.
+func CollapseWildcards(assets []Asset) []Asset {
+	var bases []string
+	for _, a := range assets {
+		if host, wildcard, ok := ScopeHost(a); ok && wildcard {
+			bases = append(bases, host)
+		}
+	}
+	if len(bases) == 0 {
+		return assets
+	}
+
+	kept := make([]Asset, 0, len(assets))
+	for _, a := range assets {
+		host, _, ok := ScopeHost(a)
+		if ok && coveredByWildcard(host, bases) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// ScopeHost extrae el hostname de un activo URL/WILDCARD. Indica si el activo
+// es un wildcard (*.host) y devuelve ok=false cuando el identificador no es un
+// hostname simple (incluye ruta, puerto, query o un wildcard intermedio).
+func ScopeHost(a Asset) (host string, wildcard bool, ok bool) {
+	if a.Type != AssetTypeURL && a.Type != AssetTypeWildcard {
+		return "", false, false
+	}
+	id := strings.ToLower(strings.TrimSpace(a.Identifier))
+	if i := strings.Index(id, "://"); i >= 0 {
+		id = id[i+3:]
+	}
+	id = strings.TrimSuffix(id, "/")
+	if strings.HasPrefix(id, "*.") {
+		wildcard = true
+		id = id[2:]
+	}
+	if id == "" || strings.ContainsAny(id, "*/:?#@ ") {
+		return "", false, false
+	}
+	return id, wildcard, true
+}
+
+// coveredByWildcard indica si host es un subdominio estricto de alguna base.
+func coveredByWildcard(host string, bases []string) bool {
+	for _, base := range bases {
+		if strings.HasSuffix(host, "."+base) {
+			return true
+		}
+	}
+	return false
+}
+
+// SafeFileName convierte un handle en un nombre de archivo seguro: solo letras,
+// dígitos, '.', '-' y '_', sin separadores de ruta ni nombres especiales.
+func SafeFileName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+	if strings.Trim(name, ".") == "" {
+		return "_" + name
+	}
+	return name
+}
@@ -0,0 +1,184 @@
+// Package plugin ejecuta fetchers de terceros: binarios sabb-fetcher-<nombre>
+// que hablan JSON por stdin/stdout, para añadir plataformas privadas o poco
+// comunes sin modificar sabb.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Plugins (sabb-fetcher-<nombre>)
+ *****************/
+
+// BinaryPrefix es el prefijo de los ejecutables de plugin en el PATH:
+// -program acme ejecuta sabb-fetcher-acme.
+const BinaryPrefix = "sabb-fetcher-"
+
+// Request es el documento que el plugin recibe por stdin antes de empezar.
+// Las credenciales de las plataformas integradas no se reenvían: el plugin
+// lee las suyas de su propio entorno o configuración.
+type Request struct {
+	Name       string `json:"name"`
+	IncludeVDP bool   `json:"include_vdp"`
+	MaxPages   int    `json:"max_pages,omitempty"`
+}
+
+// Program es cada línea JSON que el plugin escribe en stdout. Los activos
+// usan los mismos campos que -format jsonl (asset, asset_type, instruction,
+// eligible_for_bounty); platform es opcional y por defecto vale el nombre del
+// plugin.
+type Program struct {
+	Handle         string            `json:"handle"`
+	Platform       string            `json:"platform"`
+	OffersBounties bool              `json:"offers_bounties"`
+	Assets         []platforms.Asset `json:"assets"`
+	OutOfScope     []platforms.Asset `json:"out_of_scope"`
+}
+
+// Fetcher ejecuta el plugin Path y pasa cada programa que emite por la misma
+// salida que el resto de plataformas. Lo que el plugin escribe en stderr se
+// muestra tal cual; un código de salida distinto de cero es un error.
+type Fetcher struct {
+	// Name es el nombre de la plataforma (lo que va tras BinaryPrefix).
+	Name string
+	// Path es el ejecutable; ver Lookup.
+	Path string
+	// Exclusions, si no es nil, recibe el out_of_scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+	// MaxPages se pasa al plugin como límite orientativo (0 = sin límite).
+	MaxPages int
+}
+
+// Lookup busca en el PATH el plugin de la plataforma name.
+func Lookup(name string) (string, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	path, err := exec.LookPath(BinaryPrefix + name)
+	return path, err == nil
+}
+
+// Discover devuelve, ordenados, los nombres de los plugins instalados en el
+// PATH. Si dos directorios tienen el mismo plugin cuenta el primero, como en
+// Lookup.
+func Discover() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		matches, _ := filepath.Glob(filepath.Join(dir, BinaryPrefix+"*"))
+		for _, m := range matches {
+			name := strings.TrimPrefix(filepath.Base(m), BinaryPrefix)
+			if seen[name] {
+				continue
+			}
+			if _, ok := Lookup(name); ok {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f Fetcher) Fetch(ctx context.Context, _ platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	req, err := json.Marshal(Request{Name: f.Name, IncludeVDP: f.IncludeVDP, MaxPages: f.MaxPages})
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.CommandContext(ctx, f.Path)
+	cmd.Stdin = bytes.NewReader(append(req, '\n'))
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("plugin %s: %w", f.Name, err)
+	}
+
+	processed, err := f.readPrograms(stdout, out)
+	if err != nil {
+		// Se deja de leer: el plugin no debe quedarse bloqueado escribiendo.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return processed, err
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+		return processed, fmt.Errorf("plugin %s: %w", f.Name, err)
+	}
+	return processed, nil
+}
+
+// readPrograms decodifica los programas que el plugin escribe en r y los
+// escribe en out hasta el final de la salida.
+func (f Fetcher) readPrograms(r io.Reader, out platforms.AssetWriter) (int, error) {
+	dec := json.NewDecoder(r)
+	processed := 0
+	for n := 1; ; n++ {
+		var p Program
+		if err := dec.Decode(&p); err != nil {
+			if errors.Is(err, io.EOF) {
+				return processed, nil
+			}
+			return processed, fmt.Errorf("plugin %s: program #%d: %w", f.Name, n, err)
+		}
+		if p.Handle == "" {
+			return processed, fmt.Errorf("plugin %s: program #%d has no handle", f.Name, n)
+		}
+		if !p.OffersBounties && !f.IncludeVDP {
+			continue
+		}
+		platform := p.Platform
+		if platform == "" {
+			platform = f.Name
+		}
+		platforms.Processing(p.Handle)
+		if err := platforms.WriteProgram(out, f.Exclusions, platforms.Program{
+			Platform:       platform,
+			Handle:         p.Handle,
+			OffersBounties: p.OffersBounties,
+			Assets:         withPlatform(p.Assets, platform),
+			OutOfScope:     withPlatform(p.OutOfScope, platform),
+		}); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+}
+
+// withPlatform completa la plataforma de los activos y, como en -program
+// file:, deduce el tipo de los que no lo traen.
+func withPlatform(assets []platforms.Asset, platform string) []platforms.Asset {
+	for i := range assets {
+		a := &assets[i]
+		a.Identifier = strings.TrimSpace(a.Identifier)
+		if a.Platform == "" {
+			a.Platform = platform
+		}
+		if a.Type == "" {
+			a.Type = platforms.GuessAssetType(a.Identifier)
+		} else {
+			a.Type = strings.ToUpper(a.Type)
+		}
+	}
+	return assets
+}
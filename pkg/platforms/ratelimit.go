@@ -0,0 +1,174 @@
+package platforms
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*****************
+ * Límite de peticiones anunciado por la API
+ *****************/
+
+const (
+	// rateLimitLowWater es el número de peticiones restantes a partir del
+	// cual se pausa hasta el reset de la ventana.
+	rateLimitLowWater = 5
+	// maxRateLimitPause acota la pausa ante cabeceras de reset absurdas.
+	maxRateLimitPause = 5 * time.Minute
+)
+
+// rateLimitController comparte entre todas las solicitudes el estado de
+// X-RateLimit-Remaining/X-RateLimit-Reset de cada host, de modo que cuando
+// quedan pocas peticiones las siguientes esperan al reset en lugar de
+// provocar un 429.
+type rateLimitController struct {
+	mu         sync.Mutex
+	pauseUntil map[string]time.Time
+}
+
+var apiRateLimit = &rateLimitController{pauseUntil: make(map[string]time.Time)}
+
+// wait bloquea hasta que termine la pausa vigente para host o se cancele ctx.
+func (c *rateLimitController) wait(ctx context.Context, host string) error {
+	c.mu.Lock()
+	until := c.pauseUntil[host]
+	c.mu.Unlock()
+
+	d := time.Until(until)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// update registra las cabeceras de límite de una respuesta de host.
+func (c *rateLimitController) update(host string, h http.Header) {
+	remaining, err := strconv.Atoi(strings.TrimSpace(h.Get("X-RateLimit-Remaining")))
+	if err != nil || remaining > rateLimitLowWater {
+		return
+	}
+	reset, ok := parseRateLimitReset(h.Get("X-RateLimit-Reset"))
+	if !ok {
+		return
+	}
+	if max := time.Now().Add(maxRateLimitPause); reset.After(max) {
+		reset = max
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reset.After(c.pauseUntil[host]) {
+		c.pauseUntil[host] = reset
+		log.Printf("quedan %d peticiones en %s, pausa hasta %s", remaining, host, reset.Format(time.TimeOnly))
+	}
+}
+
+// parseRateLimitReset interpreta X-RateLimit-Reset, que según la API puede ser
+// un timestamp Unix o los segundos que faltan para el reset.
+func parseRateLimitReset(v string) (time.Time, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	if n > 1e9 {
+		return time.Unix(n, 0), true
+	}
+	return time.Now().Add(time.Duration(n) * time.Second), true
+}
+
+/*****************
+ * Límite de peticiones propio (token bucket)
+ *****************/
+
+// RateLimits es el máximo de solicitudes por minuto a cada host, compartido
+// por todas las solicitudes del proceso. Por defecto se aplica el límite
+// documentado de la API de HackerOne (600 lecturas por minuto). Se ajusta
+// antes de empezar a hacer solicitudes; un valor <= 0 no limita.
+var RateLimits = map[string]int{"api.hackerone.com": 600}
+
+// tokenBucket reparte rate solicitudes por segundo con ráfagas de hasta
+// burst. Los tokens pueden quedar en negativo: cada solicitud reserva el suyo
+// y espera a que se genere, de modo que se atienden por orden de llegada.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	rate := float64(perMinute) / 60
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait reserva un token y espera a que esté disponible o se cancele ctx.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var d time.Duration
+	if b.tokens < 0 {
+		d = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// El token reservado se devuelve para no penalizar a los demás.
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var buckets = struct {
+	mu sync.Mutex
+	m  map[string]*tokenBucket
+}{m: make(map[string]*tokenBucket)}
+
+// waitToken aplica RateLimits a una solicitud a host.
+func waitToken(ctx context.Context, host string) error {
+	buckets.mu.Lock()
+	b, ok := buckets.m[host]
+	if !ok {
+		if n := RateLimits[host]; n > 0 {
+			b = newTokenBucket(n)
+		}
+		buckets.m[host] = b
+	}
+	buckets.mu.Unlock()
+	if b == nil {
+		return nil
+	}
+	return b.wait(ctx)
+}
@@ -0,0 +1,102 @@
+package platforms
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+/*****************
+ * Caché de scopes
+ *****************/
+
+// ScopeCache es una caché LRU acotada de scopes ya descargados, con clave
+// plataforma+handle. Con varias cuentas o plataformas que comparten programas
+// evita repetir la misma consulta de scope dentro de una ejecución. Es segura
+// para uso concurrente; un valor nil desactiva la caché.
+type ScopeCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // frente = uso más reciente
+	entries map[string]*list.Element
+	// loading marca los scopes que se están descargando; quien llega después
+	// espera al cierre del canal en vez de repetir la consulta.
+	loading map[string]chan struct{}
+}
+
+type scopeCacheEntry struct {
+	key      string
+	assets   []Asset
+	excluded []Asset
+}
+
+// NewScopeCache crea una caché de como mucho size scopes; con size <= 0
+// devuelve nil (sin caché).
+func NewScopeCache(size int) *ScopeCache {
+	if size <= 0 {
+		return nil
+	}
+	return &ScopeCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		loading: make(map[string]chan struct{}),
+	}
+}
+
+// Load devuelve el scope de handle desde la caché o, si no está, lo obtiene
+// con fetch y lo guarda. Los errores no se guardan. Lo devuelto es una copia
+// que el llamador puede modificar.
+func (c *ScopeCache) Load(ctx context.Context, platform, handle string, fetch func() (assets, excluded []Asset, err error)) ([]Asset, []Asset, error) {
+	if c == nil {
+		return fetch()
+	}
+	key := platform + "/" + handle
+	for {
+		c.mu.Lock()
+		if el, ok := c.entries[key]; ok {
+			c.order.MoveToFront(el)
+			e := el.Value.(*scopeCacheEntry)
+			c.mu.Unlock()
+			return append([]Asset(nil), e.assets...), append([]Asset(nil), e.excluded...), nil
+		}
+		if wait, busy := c.loading[key]; busy {
+			c.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		c.loading[key] = done
+		c.mu.Unlock()
+
+		assets, excluded, err := fetch()
+
+		c.mu.Lock()
+		delete(c.loading, key)
+		if err == nil {
+			c.add(&scopeCacheEntry{
+				key:      key,
+				assets:   append([]Asset(nil), assets...),
+				excluded: append([]Asset(nil), excluded...),
+			})
+		}
+		c.mu.Unlock()
+		close(done)
+		return assets, excluded, err
+	}
+}
+
+// add inserta e expulsando la entrada menos usada si se supera size. Requiere
+// c.mu.
+func (c *ScopeCache) add(e *scopeCacheEntry) {
+	c.entries[e.key] = c.order.PushFront(e)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*scopeCacheEntry).key)
+	}
+}
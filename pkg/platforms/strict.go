@@ -0,0 +1,150 @@
+package platforms
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+/*****************
+ * Decodificación estricta (-strict)
+ *****************/
+
+// Unmarshal decodifica una respuesta de la API: con strict (-strict) mediante
+// StrictUnmarshal y, si no, con SafeUnmarshal.
+func Unmarshal(data []byte, v interface{}, strict bool) error {
+	if strict {
+		return StrictUnmarshal(data, v)
+	}
+	return SafeUnmarshal(data, v)
+}
+
+// StrictUnmarshal decodifica con DisallowUnknownFields: los campos que los
+// tipos no modelan y los datos sobrantes tras el documento son un error. Sirve
+// para detectar cambios de formato de la API (p. ej. un campo renombrado) que
+// con la decodificación tolerante solo se manifiestan como resultados vacíos.
+// El error enumera todos los campos desconocidos, no solo el primero.
+func StrictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var raw interface{}
+		if json.Unmarshal(data, &raw) == nil {
+			var unknown []string
+			unknownFields(raw, reflect.TypeOf(v), "", &unknown)
+			if len(unknown) > 0 {
+				sort.Strings(unknown)
+				return fmt.Errorf("decodificación JSON estricta fallida: campos no reconocidos: %s", strings.Join(unknown, ", "))
+			}
+		}
+		return fmt.Errorf("decodificación JSON estricta fallida: %w", err)
+	}
+	if dec.More() {
+		return errors.New("decodificación JSON estricta fallida: datos sobrantes tras el documento")
+	}
+	return nil
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unknownFields añade a out las rutas (p. ej. data[].attributes.name) de los
+// campos de raw que t no modela. No desciende por los tipos que se decodifican
+// a sí mismos ni por interface{}.
+func unknownFields(raw interface{}, t reflect.Type, path string, out *[]string) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for key, value := range obj {
+			ft, ok := fields[key]
+			if !ok {
+				// encoding/json admite también el nombre sin distinguir mayúsculas.
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						ft, ok = f, true
+						break
+					}
+				}
+			}
+			sub := key
+			if path != "" {
+				sub = path + "." + key
+			}
+			if !ok {
+				*out = append(*out, sub)
+				continue
+			}
+			unknownFields(value, ft, sub, out)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for _, item := range list {
+			unknownFields(item, t.Elem(), path+"[]", out)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, value := range obj {
+			unknownFields(value, t.Elem(), path+".*", out)
+		}
+	}
+}
+
+// jsonFields devuelve los campos JSON de un struct por nombre, incluidos los
+// de los structs embebidos sin etiqueta, como los ve encoding/json.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				for n, ft := range jsonFields(et) {
+					if _, ok := fields[n]; !ok {
+						fields[n] = ft
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,38 @@
+package platforms
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictUnmarshalRejectsUnknownFields(t *testing.T) {
+	type scope struct {
+		Data []struct {
+			Attributes struct {
+				AssetIdentifier string `json:"asset_identifier"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	// asset_identifier renombrado: en modo laxo solo queda un activo vacío.
+	body := []byte(`{"data":[{"attributes":{"asset_identifier_v2":"*.acme.com","asset_kind":"WILDCARD"}}]}`)
+
+	var lenient scope
+	if err := Unmarshal(body, &lenient, false); err != nil {
+		t.Fatalf("decodificación laxa: %v", err)
+	}
+
+	var strict scope
+	err := Unmarshal(body, &strict, true)
+	if err == nil {
+		t.Fatal("-strict aceptó campos no reconocidos")
+	}
+	for _, field := range []string{"data[].attributes.asset_identifier_v2", "data[].attributes.asset_kind"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("el error %q no menciona %s", err, field)
+		}
+	}
+
+	if err := StrictUnmarshal([]byte(`{"data":[]} {}`), &strict); err == nil {
+		t.Error("-strict aceptó datos sobrantes tras el documento")
+	}
+}
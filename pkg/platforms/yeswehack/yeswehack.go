@@ -0,0 +1,207 @@
+// Package yeswehack descarga el scope de los programas de YesWeHack con un
+// token de la API.
+package yeswehack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * YesWeHack
+ *****************/
+
+// pageSize es el tamaño de página del listado de programas.
+const pageSize = 100
+
+// Fetcher descarga el scope de los programas de YesWeHack con un
+// token de la API (Bearer).
+type Fetcher struct {
+	// Client se comparte con el resto de plataformas; si es nil se crea uno.
+	Client *http.Client
+	// Exclusions, si no es nil, recibe el out_of_scope.
+	Exclusions platforms.AssetWriter
+	// IncludeVDP procesa también los programas sin recompensas.
+	IncludeVDP bool
+	// Strict decodifica las respuestas con platforms.StrictUnmarshal, que
+	// falla ante los campos desconocidos.
+	Strict bool
+	// MaxPages limita las páginas del listado de programas (0 = sin límite).
+	MaxPages int
+}
+
+type programsPage struct {
+	Items []struct {
+		Slug   string `json:"slug"`
+		Title  string `json:"title"`
+		Bounty bool   `json:"bounty"`
+	} `json:"items"`
+	Pagination struct {
+		Page    int `json:"page"`
+		NbPages int `json:"nb_pages"`
+	} `json:"pagination"`
+}
+
+type program struct {
+	Scopes []struct {
+		Scope     string `json:"scope"`
+		ScopeType string `json:"scope_type"`
+	} `json:"scopes"`
+	OutOfScope []string `json:"out_of_scope"`
+}
+
+func (y Fetcher) Fetch(ctx context.Context, creds platforms.Credentials, out platforms.AssetWriter) (int, error) {
+	client := y.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if creds.Token == "" {
+		return 0, fmt.Errorf("%w: YesWeHack requiere un token", platforms.ErrInvalidCredentials)
+	}
+	header := http.Header{"Authorization": {"Bearer " + creds.Token}}
+
+	processed := 0
+	for page := 1; ; page++ {
+		select {
+		case <-ctx.Done():
+			return processed, ctx.Err()
+		default:
+		}
+		if y.MaxPages > 0 && page > y.MaxPages {
+			log.Printf("aviso: alcanzado el límite de %d páginas de programas de YesWeHack (-max-pages)", y.MaxPages)
+			platforms.PageLimitReached(ctx)
+			break
+		}
+
+		url := fmt.Sprintf("https://api.yeswehack.com/programs?page=%d&resultsPerPage=%d", page, pageSize)
+		body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+		if err != nil {
+			return processed, fmt.Errorf("programs page request failed: %w", err)
+		}
+		var pg programsPage
+		if err := platforms.Unmarshal(body, &pg, y.Strict); err != nil {
+			return processed, err
+		}
+		if len(pg.Items) == 0 {
+			break
+		}
+
+		for _, p := range pg.Items {
+			if !p.Bounty && !y.IncludeVDP {
+				continue
+			}
+			handle := p.Slug
+			platforms.Processing(handle)
+
+			start := time.Now()
+			assets, excluded, err := y.fetchScopes(ctx, client, header, handle)
+			platforms.ObserveProgramFetch("yeswehack", start)
+			if err != nil {
+				if platforms.IsUnavailableScope(err) {
+					log.Printf("aviso: se omite %s: scope no disponible (%v)", handle, err)
+					continue
+				}
+				return processed, fmt.Errorf("program %s failed: %w", handle, err)
+			}
+			if err := platforms.WriteProgram(out, y.Exclusions, platforms.Program{Platform: "yeswehack", Handle: handle, OffersBounties: p.Bounty, Assets: assets, OutOfScope: excluded}); err != nil {
+				return processed, err
+			}
+			processed++
+		}
+
+		if pg.Pagination.NbPages > 0 && page >= pg.Pagination.NbPages {
+			break
+		}
+	}
+	return processed, nil
+}
+
+// fetchScopes devuelve los activos en scope de un programa, con las reglas
+// del tipo "(www|api).example.com" ya expandidas, y su out_of_scope.
+func (y Fetcher) fetchScopes(ctx context.Context, client *http.Client, header http.Header, slug string) (assets, excluded []platforms.Asset, err error) {
+	url := fmt.Sprintf("https://api.yeswehack.com/programs/%s", slug)
+	body, err := platforms.DoRequestWithRetry(ctx, client, url, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	var p program
+	if err := platforms.Unmarshal(body, &p, y.Strict); err != nil {
+		return nil, nil, err
+	}
+
+	for _, s := range p.Scopes {
+		for _, id := range expandScopeAlternatives(s.Scope) {
+			assets = append(assets, platforms.Asset{
+				Platform:   "yeswehack",
+				Identifier: id,
+				Type:       assetType(s.ScopeType, id),
+			})
+		}
+	}
+	for _, s := range p.OutOfScope {
+		for _, id := range expandScopeAlternatives(s) {
+			excluded = append(excluded, platforms.Asset{Platform: "yeswehack", Identifier: id})
+		}
+	}
+	return assets, excluded, nil
+}
+
+// expandScopeAlternatives expande las alternativas entre paréntesis que usa
+// YesWeHack en sus reglas de scope: "https://(www|api).example.(com|fr)" da
+// las cuatro combinaciones. Un texto sin alternativas se devuelve tal cual, y
+// los paréntesis sin '|' se conservan.
+func expandScopeAlternatives(scope string) []string {
+	scope = strings.TrimSpace(scope)
+	open := strings.Index(scope, "(")
+	if open < 0 {
+		return []string{scope}
+	}
+	end := strings.Index(scope[open:], ")")
+	if end < 0 {
+		return []string{scope}
+	}
+	end += open
+	group := scope[open+1 : end]
+	if !strings.Contains(group, "|") {
+		// Paréntesis literales: se sigue expandiendo lo que venga detrás.
+		var out []string
+		for _, rest := range expandScopeAlternatives(scope[end+1:]) {
+			out = append(out, scope[:end+1]+rest)
+		}
+		return out
+	}
+
+	var out []string
+	for _, alt := range strings.Split(group, "|") {
+		out = append(out, expandScopeAlternatives(scope[:open]+alt+scope[end+1:])...)
+	}
+	return out
+}
+
+// assetType traduce el scope_type de YesWeHack a los tipos de activo
+// de HackerOne que usa el resto de la herramienta.
+func assetType(scopeType, identifier string) string {
+	switch scopeType {
+	case "web-application", "api":
+		if strings.HasPrefix(identifier, "*.") {
+			return platforms.AssetTypeWildcard
+		}
+		return platforms.AssetTypeURL
+	case "ip-address":
+		if strings.Contains(identifier, "/") {
+			return platforms.AssetTypeCIDR
+		}
+		return platforms.AssetTypeIPAddress
+	case "mobile-application-android":
+		return "GOOGLE_PLAY_APP_ID"
+	case "mobile-application-ios":
+		return "APPLE_STORE_APP_ID"
+	}
+	return "OTHER"
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+	"github.com/betillogalvanfbc/sabb/pkg/platforms/plugin"
+)
+
+/*****************
+ * Plataformas soportadas
+ *****************/
+
+// Tipos de pkg/platforms que usa el resto de la CLI.
+type (
+	Asset           = platforms.Asset
+	Program         = platforms.Program
+	Credentials     = platforms.Credentials
+	AssetWriter     = platforms.AssetWriter
+	ProgramFetcher  = platforms.Fetcher
+	APIError        = platforms.APIError
+	assetWriterFunc = platforms.AssetWriterFunc
+)
+
+// filePlatformPrefix marca en -program una lista de programas local en JSON o
+// YAML en lugar de una plataforma, p. ej. -program file:./privados.yaml.
+const filePlatformPrefix = "file:"
+
+// init conecta los eventos de los fetchers con las métricas, las
+// estadísticas por plataforma y el progreso en stderr.
+func init() {
+	platforms.Hooks.Request = func(ctx context.Context) {
+		apiRequestsTotal.Inc()
+		statsFromContext(ctx).addRequest()
+	}
+	platforms.Hooks.Error = func(ctx context.Context, status int) {
+		recordAPIError(status)
+		statsFromContext(ctx).addError()
+	}
+	platforms.Hooks.Retry = apiRetriesTotal.Inc
+	platforms.Hooks.ProgramFetched = func(platform string, d time.Duration) {
+		programFetchSeconds.WithLabelValues(platform).Observe(d.Seconds())
+	}
+	platforms.Hooks.Processing = func(handle string) {
+		fmt.Fprintf(os.Stderr, "Procesando: %s\n", stderrColor.cyan(handle))
+	}
+	platforms.Hooks.PageLimit = func(ctx context.Context) {
+		statsFromContext(ctx).markTruncated()
+	}
+}
+
+// platformInfo describe una plataforma registrada para -list-platforms.
+type platformInfo struct {
+	name        string
+	implemented bool
+	// credentials es la forma de credencial que espera la plataforma.
+	credentials string
+	// public indica que la plataforma no necesita credenciales.
+	public bool
+}
+
+// supportedPlatforms debe mantenerse al día con el mapa de fetchers de run().
+var supportedPlatforms = []platformInfo{
+	{name: "hackerone", implemented: true, credentials: "username:apikey"},
+	{name: "intigriti", implemented: true, credentials: "token (-intigriti-token o -apikey)"},
+	{name: "bugcrowd", implemented: true, credentials: "token (-bugcrowd-token o -apikey)"},
+	{name: "yeswehack", implemented: true, credentials: "token (-yeswehack-token o -apikey)"},
+	{name: "hackenproof", implemented: true, credentials: "ninguna (datos públicos)", public: true},
+	{name: "immunefi", implemented: true, credentials: "ninguna (datos públicos)", public: true},
+	{name: "bountytargets", implemented: true, credentials: "ninguna (datos públicos)", public: true},
+	{name: "openbugbounty", implemented: true, credentials: "ninguna (datos públicos)", public: true},
+	{name: filePlatformPrefix + "<ruta>", implemented: true, credentials: "ninguna (archivo JSON o YAML local)", public: true},
+}
+
+// isPublicPlatform indica si la plataforma se consulta sin credenciales.
+func isPublicPlatform(name string) bool {
+	if strings.HasPrefix(name, filePlatformPrefix) {
+		return true
+	}
+	for _, p := range supportedPlatforms {
+		if p.name == name {
+			return p.public
+		}
+	}
+	// Los plugins gestionan sus propias credenciales.
+	_, ok := plugin.Lookup(name)
+	return ok
+}
+
+// printPlatforms escribe una línea por plataforma con su estado y credencial.
+func printPlatforms(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, p := range supportedPlatforms {
+		status := "stub (no implementado)"
+		if p.implemented {
+			status = "implementado"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", p.name, status, p.credentials)
+	}
+	for _, name := range plugin.Discover() {
+		fmt.Fprintf(tw, "%s\tplugin\tla que lea el plugin (%s%s)\n", name, plugin.BinaryPrefix, name)
+	}
+	return tw.Flush()
+}
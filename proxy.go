@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*****************
+ * Rotación de proxies (-proxy-list)
+ *****************/
+
+// proxyCooldown es el tiempo que un proxy fallido queda fuera de la rotación.
+const proxyCooldown = time.Minute
+
+// proxyRotator reparte las solicitudes entre varios proxies en round-robin,
+// saltando temporalmente los que han fallado. Es seguro para uso concurrente.
+type proxyRotator struct {
+	mu        sync.Mutex
+	proxies   []*url.URL
+	downUntil []time.Time
+	next      int
+}
+
+// loadProxyList lee un proxy por línea (http://, https:// o socks5://);
+// se ignoran las líneas vacías y las que empiezan por '#'.
+func loadProxyList(path string) (*proxyRotator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir la lista de proxies: %w", err)
+	}
+	defer f.Close()
+
+	r := &proxyRotator{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		u, err := url.Parse(line)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("%s:%d: proxy inválido %q", path, n, line)
+		}
+		r.proxies = append(r.proxies, u)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+	if len(r.proxies) == 0 {
+		return nil, fmt.Errorf("%s no contiene proxies", path)
+	}
+	r.downUntil = make([]time.Time, len(r.proxies))
+	return r, nil
+}
+
+// pick devuelve el siguiente proxy sano. Si todos están marcados como caídos
+// se usa igualmente el siguiente, para no bloquear la ejecución.
+func (r *proxyRotator) pick() (int, *url.URL) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for range r.proxies {
+		i := r.next
+		r.next = (r.next + 1) % len(r.proxies)
+		if now.After(r.downUntil[i]) {
+			return i, r.proxies[i]
+		}
+	}
+	i := r.next
+	r.next = (r.next + 1) % len(r.proxies)
+	return i, r.proxies[i]
+}
+
+// markDown saca el proxy i de la rotación durante proxyCooldown.
+func (r *proxyRotator) markDown(i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().After(r.downUntil[i]) {
+		log.Printf("aviso: proxy %s no responde, se omite durante %s", r.proxies[i].Redacted(), proxyCooldown)
+	}
+	r.downUntil[i] = time.Now().Add(proxyCooldown)
+}
+
+type proxyContextKey struct{}
+
+// proxyTransport elige un proxy por solicitud y marca como caído el que
+// produzca un error de transporte.
+type proxyTransport struct {
+	rotator *proxyRotator
+	base    *http.Transport
+}
+
+// newProxyTransport crea el transporte de rotación sobre una copia de base.
+func newProxyTransport(rotator *proxyRotator, base *http.Transport) *proxyTransport {
+	t := base.Clone()
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		u, _ := req.Context().Value(proxyContextKey{}).(*url.URL)
+		return u, nil
+	}
+	return &proxyTransport{rotator: rotator, base: t}
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	i, u := t.rotator.pick()
+	req = req.WithContext(context.WithValue(req.Context(), proxyContextKey{}, u))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && req.Context().Err() == nil {
+		t.rotator.markDown(i)
+	}
+	return resp, err
+}
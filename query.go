@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+/*****************
+ * Consultas al almacén (sabb export, query, serve)
+ *****************/
+
+// storedAssetsSQL devuelve los activos de -store que cumplen los filtros; un
+// filtro vacío no restringe nada.
+const storedAssetsSQL = `SELECT a.platform, a.handle, a.identifier, a.asset_type, a.instruction,
+	a.eligible_for_bounty, COALESCE(p.offers_bounties, a.eligible_for_bounty), a.first_seen, a.last_seen, a.removed_at
+FROM assets a LEFT JOIN programs p ON p.platform = a.platform AND p.handle = a.handle
+WHERE ($1 = '' OR a.platform = $1)
+	AND ($2 = '' OR a.handle = $2)
+	AND ($3 = '' OR UPPER(a.asset_type) = $3)
+	AND ($4 = '' OR LOWER(a.identifier) LIKE '%' || $4 || '%')
+	AND ($5 OR a.removed_at IS NULL)
+ORDER BY a.platform, a.handle, a.identifier`
+
+// storeFilter son los filtros comunes de las órdenes que leen -store.
+type storeFilter struct {
+	platform, handle, assetType, contains string
+	// removed incluye también los activos que salieron del scope.
+	removed bool
+	// bountyOnly descarta los activos no elegibles para recompensa.
+	bountyOnly bool
+}
+
+// register añade los flags de los filtros a fs.
+func (f *storeFilter) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.platform, "platform", "", "Solo activos de esta plataforma")
+	fs.StringVar(&f.handle, "handle", "", "Solo activos de este programa")
+	fs.StringVar(&f.assetType, "type", "", "Solo activos de este tipo (URL, WILDCARD, CIDR...)")
+	fs.StringVar(&f.contains, "contains", "", "Solo activos cuyo identificador contiene este texto")
+	fs.BoolVar(&f.removed, "include-removed", false, "Incluye los activos que ya salieron del scope")
+	fs.BoolVar(&f.bountyOnly, "bounty-only", false, "Solo activos elegibles para recompensa")
+}
+
+// storedAsset es un activo de -store con su historial.
+type storedAsset struct {
+	Asset
+	firstSeen, lastSeen time.Time
+	removedAt           sql.NullTime
+}
+
+func queryStoredAssets(ctx context.Context, db *sql.DB, f storeFilter) ([]storedAsset, error) {
+	rows, err := db.QueryContext(ctx, storedAssetsSQL,
+		strings.ToLower(strings.TrimSpace(f.platform)),
+		strings.TrimSpace(f.handle),
+		strings.ToUpper(strings.TrimSpace(f.assetType)),
+		strings.ToLower(strings.TrimSpace(f.contains)),
+		f.removed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var assets []storedAsset
+	for rows.Next() {
+		var a storedAsset
+		var eligible bool
+		if err := rows.Scan(&a.Platform, &a.Handle, &a.Identifier, &a.Type, &a.Instruction,
+			&eligible, &a.OffersBounties, &a.firstSeen, &a.lastSeen, &a.removedAt); err != nil {
+			return nil, err
+		}
+		if f.bountyOnly && !eligible {
+			continue
+		}
+		a.EligibleForBounty = &eligible
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+// encodeStoredAssets escribe assets en w con el formato indicado.
+func encodeStoredAssets(w io.Writer, format string, assets []storedAsset) error {
+	bw := bufio.NewWriter(w)
+	enc, err := newAssetEncoder(format, bw, encoderOptions{})
+	if err != nil {
+		return err
+	}
+	for _, a := range assets {
+		if err := enc.WriteAsset(a.Asset); err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// runExport implementa "sabb export -store <almacén> [-format burp] [-output
+// archivo]": escribe el scope guardado sin volver a consultar las plataformas.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	config := configFlag(fs)
+	spec := fs.String("store", "", "Almacén de -store que exportar (p. ej. sqlite:scopes.db)")
+	formatFlag := fs.String("format", "", "Formato de salida: "+formatNames()+" (por defecto se deduce de la extensión de -output, o txt)")
+	output := fs.String("output", "-", "Destino: archivo, - (stdout), s3://bucket/key o http(s)://url (POST)")
+	var filter storeFilter
+	filter.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb export -store <almacén> [-format <formato>] [-output <destino>]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyConfig(fs, *config, "export"); err != nil {
+		return err
+	}
+	if *spec == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("export: se espera -store")
+	}
+	format := *formatFlag
+	if format == "" {
+		format = formatFromOutput(*output)
+	}
+	if _, ok := lookupFormat(format); !ok {
+		return fmt.Errorf("formato desconocido %q: se admite %s", format, formatNames())
+	}
+
+	st, err := openAssetStore(*spec)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	assets, err := queryStoredAssets(context.Background(), st.db, filter)
+	if err != nil {
+		return err
+	}
+	dst, err := openOutput(*output, 0644, formatAppends(format), formatContentType(format))
+	if err != nil {
+		return err
+	}
+	if err := encodeStoredAssets(dst, format, assets); err != nil {
+		dst.Close()
+		return fmt.Errorf("no se pudo escribir %s: %w", *output, err)
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d activos exportados\n", len(assets))
+	return nil
+}
+
+// runQuery implementa "sabb query -store <almacén> [filtros]": una tabla con
+// los activos que coinciden y su historial.
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	config := configFlag(fs)
+	spec := fs.String("store", "", "Almacén de -store que consultar (p. ej. sqlite:scopes.db)")
+	var filter storeFilter
+	filter.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb query -store <almacén> [-platform p] [-handle h] [-type t] [-contains texto]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyConfig(fs, *config, "query"); err != nil {
+		return err
+	}
+	// Un argumento suelto equivale a -contains.
+	if fs.NArg() == 1 && filter.contains == "" {
+		filter.contains = fs.Arg(0)
+	} else if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("query: se espera como mucho un texto a buscar")
+	}
+	if *spec == "" {
+		fs.Usage()
+		return errors.New("query: se espera -store")
+	}
+	st, err := openAssetStore(*spec)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	assets, err := queryStoredAssets(context.Background(), st.db, filter)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return errors.New("query: ningún activo coincide")
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Activo\tPrograma\tTipo\tBounty\tPrimera vez\tÚltima vez\tEliminado")
+	for _, a := range assets {
+		bounty := "no"
+		if a.BountyEligible() {
+			bounty = "sí"
+		}
+		fmt.Fprintf(tw, "%s\t%s/%s\t%s\t%s\t%s\t%s\t%s\n", a.Identifier, a.Platform, a.Handle, a.Type, bounty,
+			historyTime(a.firstSeen), historyTime(a.lastSeen), historyRemoved(a.removedAt))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d activos\n", len(assets))
+	return nil
+}
+
+// runServe implementa "sabb serve -store <almacén> -addr :8080": expone el
+// scope guardado en GET /assets, con los filtros de query como parámetros
+// (?platform=&handle=&type=&contains=&include_removed=&bounty_only=) y
+// ?format= (jsonl por defecto), para que otras herramientas lo consuman sin
+// acceso a la base de datos. Se detiene con Ctrl-C.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	config := configFlag(fs)
+	spec := fs.String("store", "", "Almacén de -store que servir (p. ej. sqlite:scopes.db)")
+	addr := fs.String("addr", "127.0.0.1:8080", "Dirección en la que escuchar")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Uso: sabb serve -store <almacén> [-addr 127.0.0.1:8080]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := applyConfig(fs, *config, "serve"); err != nil {
+		return err
+	}
+	if *spec == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("serve: se espera -store")
+	}
+	st, err := openAssetStore(*spec)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/assets", func(w http.ResponseWriter, r *http.Request) {
+		serveAssets(w, r, st.db)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if err := st.db.PingContext(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	log.Printf("sirviendo %s en http://%s/assets", *spec, *addr)
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdown)
+}
+
+func serveAssets(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if _, ok := lookupFormat(format); !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	filter := storeFilter{
+		platform:   q.Get("platform"),
+		handle:     q.Get("handle"),
+		assetType:  q.Get("type"),
+		contains:   q.Get("contains"),
+		removed:    q.Get("include_removed") == "true",
+		bountyOnly: q.Get("bounty_only") == "true",
+	}
+	assets, err := queryStoredAssets(r.Context(), db, filter)
+	if err != nil {
+		log.Printf("serve: %v", err)
+		http.Error(w, "query failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", formatContentType(format))
+	if err := encodeStoredAssets(w, format, assets); err != nil {
+		log.Printf("serve: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+/*****************
+ * Política de redirecciones (-max-redirects)
+ *****************/
+
+// redirectPolicy devuelve un CheckRedirect que sigue como mucho max saltos y
+// registra cada uno, para que una redirección inesperada (p. ej. a una página
+// de login que devuelve HTML) no pase desapercibida. La cabecera
+// Authorization se conserva solo si el salto es al mismo host; hacia otro host
+// se elimina para no filtrar las credenciales. Con max <= 0 no se sigue
+// ninguna redirección y el llamador recibe la respuesta 3xx.
+func redirectPolicy(max int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		prev := via[len(via)-1]
+		if max <= 0 {
+			log.Printf("aviso: redirección no seguida (-max-redirects 0): %s -> %s", prev.URL.Redacted(), req.URL.Redacted())
+			return http.ErrUseLastResponse
+		}
+		if len(via) > max {
+			return fmt.Errorf("demasiadas redirecciones (más de %d, -max-redirects)", max)
+		}
+		log.Printf("aviso: redirección %s -> %s", prev.URL.Redacted(), req.URL.Redacted())
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			req.Header.Del("Authorization")
+		} else if auth := via[0].Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+/*****************
+ * Informe por tipo de activo (-report)
+ *****************/
+
+// assetReport cuenta los activos emitidos por plataforma y tipo de activo.
+type assetReport struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+func newAssetReport() *assetReport {
+	return &assetReport{counts: make(map[string]map[string]int)}
+}
+
+// add contabiliza un activo; un reporte nil no cuenta nada.
+func (r *assetReport) add(a Asset) {
+	if r == nil {
+		return
+	}
+	typ := a.Type
+	if typ == "" {
+		typ = "UNKNOWN"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byType, ok := r.counts[a.Platform]
+	if !ok {
+		byType = make(map[string]int)
+		r.counts[a.Platform] = byType
+	}
+	byType[typ]++
+}
+
+// wrap contabiliza cada activo antes de pasarlo a next.
+func (r *assetReport) wrap(next AssetWriter) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		r.add(a)
+		return next.WriteAsset(a)
+	})
+}
+
+// print escribe el recuento agrupado por plataforma, de mayor a menor.
+func (r *assetReport) print(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	platforms := make([]string, 0, len(r.counts))
+	for p := range r.counts {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Activos por tipo:")
+	for _, p := range platforms {
+		byType := r.counts[p]
+		types := make([]string, 0, len(byType))
+		total := 0
+		for t, n := range byType {
+			types = append(types, t)
+			total += n
+		}
+		sort.Slice(types, func(i, j int) bool {
+			if byType[types[i]] != byType[types[j]] {
+				return byType[types[i]] > byType[types[j]]
+			}
+			return types[i] < types[j]
+		})
+
+		fmt.Fprintf(tw, "%s:\n", p)
+		for _, t := range types {
+			fmt.Fprintf(tw, "  %s\t%d\n", t, byType[t])
+		}
+		fmt.Fprintf(tw, "  total\t%d\n", total)
+	}
+	tw.Flush()
+}
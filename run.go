@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+/*****************
+ * Ejecución concurrente de plataformas
+ *****************/
+
+// platformJob es una ejecución de un fetcher con unas credenciales concretas.
+// Con varias cuentas de HackerOne hay un trabajo por cuenta.
+type platformJob struct {
+	platform string
+	label    string
+	fetcher  ProgramFetcher
+	creds    Credentials
+}
+
+type platformResult struct {
+	job       platformJob
+	processed int
+	err       error
+}
+
+// runPlatforms ejecuta los trabajos de forma concurrente, con como mucho limit
+// a la vez, y devuelve sus resultados en el mismo orden que jobs. out debe
+// admitir escrituras concurrentes. Con ordered, la salida de cada trabajo se
+// escribe completa y en el orden de jobs en vez de intercalarse. Si stats no es
+// nil, cada trabajo registra en él sus programas, activos, solicitudes y
+// errores bajo su label.
+func runPlatforms(ctx context.Context, jobs []platformJob, out AssetWriter, limit int, ordered bool, stats *Stats) []platformResult {
+	if limit < 1 {
+		limit = 1
+	}
+	results := make([]platformResult, len(jobs))
+	sem := make(chan struct{}, limit)
+
+	var rb *reorderBuffer
+	if ordered {
+		rb = newReorderBuffer(out)
+	}
+
+	// Los contadores se registran antes de lanzar los trabajos para que el
+	// resumen siga el orden de jobs.
+	counters := make([]*platformStats, len(jobs))
+	for i, job := range jobs {
+		counters[i] = stats.platform(job.label)
+	}
+
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job platformJob) {
+			defer wg.Done()
+			ps := counters[i]
+			w := out
+			if rb != nil {
+				w = rb.writer(i)
+				defer func() {
+					if err := rb.finish(i); err != nil && results[i].err == nil {
+						results[i].err = err
+					}
+				}()
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = platformResult{job: job, err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			// Un panic en un fetcher se convierte en error de su plataforma
+			// para que main siga entregando los activos ya recogidos.
+			defer func() {
+				if r := recover(); r != nil {
+					results[i] = platformResult{job: job, err: fmt.Errorf("panic en el fetcher: %v", r)}
+				}
+			}()
+
+			n, err := job.fetcher.Fetch(withPlatformStats(ctx, ps), job.creds, ps.wrap(w))
+			ps.addPrograms(n)
+			if err != nil {
+				ps.addError()
+			}
+			results[i] = platformResult{job: job, processed: n, err: err}
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
+
+// reorderBuffer entrega los activos de varias secuencias concurrentes en
+// orden de secuencia, como parallel --keep-order: la secuencia en curso se
+// escribe directamente y las posteriores se acumulan hasta que terminan todas
+// las anteriores.
+type reorderBuffer struct {
+	mu      sync.Mutex
+	out     AssetWriter
+	next    int
+	pending map[int][]Asset
+	done    map[int]bool
+	err     error
+}
+
+func newReorderBuffer(out AssetWriter) *reorderBuffer {
+	return &reorderBuffer{out: out, pending: make(map[int][]Asset), done: make(map[int]bool)}
+}
+
+// writer devuelve el AssetWriter de la secuencia seq.
+func (r *reorderBuffer) writer(seq int) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if seq == r.next {
+			return r.out.WriteAsset(a)
+		}
+		r.pending[seq] = append(r.pending[seq], a)
+		return nil
+	})
+}
+
+// finish marca seq como terminada y vuelca las secuencias siguientes que ya
+// puedan escribirse. Devuelve el primer error de escritura diferida.
+func (r *reorderBuffer) finish(seq int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done[seq] = true
+	for r.done[r.next] {
+		delete(r.done, r.next)
+		r.next++
+		for _, a := range r.pending[r.next] {
+			if err := r.out.WriteAsset(a); err != nil && r.err == nil {
+				r.err = err
+			}
+		}
+		delete(r.pending, r.next)
+	}
+	return r.err
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/betillogalvanfbc/sabb/pkg/platforms"
+)
+
+/*****************
+ * Exportación de scope para proxies (-format burp, -format zap)
+ *****************/
+
+// scopePattern es un activo traducido a las expresiones regulares que usan
+// los proxies de interceptación para delimitar el scope.
+type scopePattern struct {
+	// protocol es "http", "https" o "any" si el activo no fija esquema.
+	protocol string
+	host     string
+	// port y file quedan vacíos cuando el activo no los restringe.
+	port string
+	file string
+}
+
+// newScopePattern traduce un activo a su patrón de scope, u ok=false si su
+// tipo no se puede expresar como host (apps móviles, CIDR, código...).
+func newScopePattern(a Asset) (scopePattern, bool) {
+	p := scopePattern{protocol: "any"}
+	id := strings.ToLower(strings.TrimSpace(a.Identifier))
+	switch a.Type {
+	case platforms.AssetTypeIPAddress:
+		if net.ParseIP(id) == nil {
+			return p, false
+		}
+		p.host = "^" + regexp.QuoteMeta(id) + "$"
+		return p, true
+	case platforms.AssetTypeURL, platforms.AssetTypeWildcard:
+	default:
+		return p, false
+	}
+
+	if i := strings.Index(id, "://"); i >= 0 {
+		switch scheme := id[:i]; scheme {
+		case "http", "https":
+			p.protocol = scheme
+		}
+		id = id[i+3:]
+	}
+	host, path := id, ""
+	if i := strings.IndexAny(id, "/?#"); i >= 0 {
+		host, path = id[:i], id[i:]
+	}
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		host = h
+		p.port = "^" + regexp.QuoteMeta(port) + "$"
+	}
+	if host == "" {
+		return p, false
+	}
+
+	switch {
+	case strings.HasPrefix(host, "*."):
+		p.host = `^.+\.` + regexp.QuoteMeta(host[2:]) + "$"
+	case strings.Contains(host, "*"):
+		// Comodines en mitad del host (api.*.example.com).
+		parts := strings.Split(host, "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		p.host = "^" + strings.Join(parts, "[^.]+") + "$"
+	default:
+		p.host = "^" + regexp.QuoteMeta(host) + "$"
+	}
+	if path = strings.TrimSuffix(strings.TrimSuffix(path, "*"), "/"); path != "" {
+		p.file = "^" + regexp.QuoteMeta(path) + ".*"
+	}
+	return p, true
+}
+
+// exclusionAsset completa el tipo de una exclusión: varias plataformas solo
+// dan el identificador, y se deduce como en -program file:.
+func exclusionAsset(a Asset) Asset {
+	if a.Type == "" {
+		a.Type = platforms.GuessAssetType(strings.TrimSpace(a.Identifier))
+	}
+	return a
+}
+
+// burpEncoder genera la configuración de scope avanzado de Burp Suite
+// (Target > Scope > Load options): los activos como include y, si se recogen,
+// las exclusiones como exclude. Se escribe al cerrar.
+type burpEncoder struct {
+	w          io.Writer
+	exclusions *exclusionList
+	include    []burpScopeEntry
+	seen       map[scopePattern]bool
+	skipped    map[string]int
+}
+
+type burpScopeEntry struct {
+	Enabled  bool   `json:"enabled"`
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     string `json:"port,omitempty"`
+	File     string `json:"file,omitempty"`
+}
+
+type burpConfig struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool             `json:"advanced_mode"`
+			Include      []burpScopeEntry `json:"include"`
+			Exclude      []burpScopeEntry `json:"exclude"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+func newBurpScopeEntry(p scopePattern) burpScopeEntry {
+	return burpScopeEntry{Enabled: true, Protocol: p.protocol, Host: p.host, Port: p.port, File: p.file}
+}
+
+func (e *burpEncoder) WriteAsset(a Asset) error {
+	p, ok := newScopePattern(a)
+	if !ok {
+		e.skipped[a.Type]++
+		return nil
+	}
+	if !e.seen[p] {
+		e.seen[p] = true
+		e.include = append(e.include, newBurpScopeEntry(p))
+	}
+	return nil
+}
+
+func (e *burpEncoder) Close() error {
+	for typ, n := range e.skipped {
+		log.Printf("aviso: formato burp: %d activos de tipo %s omitidos", n, typ)
+	}
+
+	var cfg burpConfig
+	cfg.Target.Scope.AdvancedMode = true
+	cfg.Target.Scope.Include = e.include
+	cfg.Target.Scope.Exclude = []burpScopeEntry{}
+	if cfg.Target.Scope.Include == nil {
+		cfg.Target.Scope.Include = []burpScopeEntry{}
+	}
+	for _, a := range e.exclusions.list() {
+		if p, ok := newScopePattern(exclusionAsset(a)); ok {
+			cfg.Target.Scope.Exclude = append(cfg.Target.Scope.Exclude, newBurpScopeEntry(p))
+		}
+	}
+
+	enc := json.NewEncoder(e.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// urlRegex expresa el patrón como una sola expresión sobre la URL completa,
+// la forma que usan los contextos de ZAP.
+func (p scopePattern) urlRegex() string {
+	scheme := "https?"
+	if p.protocol != "any" {
+		scheme = p.protocol
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(p.host, "^"), "$")
+	port := `(:\d+)?`
+	if p.port != "" {
+		port = ":" + strings.TrimSuffix(strings.TrimPrefix(p.port, "^"), "$")
+	}
+	file := `([/?#].*)?`
+	if p.file != "" {
+		file = strings.TrimPrefix(p.file, "^")
+	}
+	return "^" + scheme + "://" + host + port + file + "$"
+}
+
+// zapEncoder genera un contexto de OWASP ZAP (File > Import Context) con los
+// activos como expresiones de inclusión y las exclusiones recogidas como
+// expresiones de exclusión. El contexto toma el handle del programa si todos
+// los activos son del mismo; si no, se llama "sabb".
+type zapEncoder struct {
+	w          io.Writer
+	exclusions *exclusionList
+	name       string
+	mixed      bool
+	include    []string
+	seen       map[string]bool
+	skipped    map[string]int
+}
+
+type zapContextFile struct {
+	XMLName xml.Name `xml:"configuration"`
+	Context struct {
+		Name       string   `xml:"name"`
+		Desc       string   `xml:"desc"`
+		InScope    bool     `xml:"inscope"`
+		IncRegexes []string `xml:"incregexes"`
+		ExcRegexes []string `xml:"excregexes"`
+	} `xml:"context"`
+}
+
+func (e *zapEncoder) WriteAsset(a Asset) error {
+	switch {
+	case e.name == "":
+		e.name = a.Handle
+	case e.name != a.Handle:
+		e.mixed = true
+	}
+	p, ok := newScopePattern(a)
+	if !ok {
+		e.skipped[a.Type]++
+		return nil
+	}
+	if re := p.urlRegex(); !e.seen[re] {
+		e.seen[re] = true
+		e.include = append(e.include, re)
+	}
+	return nil
+}
+
+func (e *zapEncoder) Close() error {
+	for typ, n := range e.skipped {
+		log.Printf("aviso: formato zap: %d activos de tipo %s omitidos", n, typ)
+	}
+
+	var cfg zapContextFile
+	cfg.Context.Name = e.name
+	if cfg.Context.Name == "" || e.mixed {
+		cfg.Context.Name = "sabb"
+	}
+	cfg.Context.Desc = "Scope generado por sabb"
+	cfg.Context.InScope = true
+	cfg.Context.IncRegexes = e.include
+	for _, a := range e.exclusions.list() {
+		if p, ok := newScopePattern(exclusionAsset(a)); ok {
+			cfg.Context.ExcRegexes = append(cfg.Context.ExcRegexes, p.urlRegex())
+		}
+	}
+
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(e.w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+/*****************
+ * Estado persistente (-state)
+ *****************/
+
+// assetState es el conjunto acumulado de activos vistos en ejecuciones
+// anteriores. Mientras está abierto mantiene un lock exclusivo sobre
+// <path>.lock para que dos ejecuciones simultáneas no pisen el archivo.
+type assetState struct {
+	path string
+	lock *os.File
+	seen map[string]struct{}
+}
+
+type assetStateFile struct {
+	Assets []string `json:"assets"`
+}
+
+// openAssetState bloquea y carga el archivo de estado. Un archivo inexistente
+// o corrupto se trata como estado vacío (con un aviso) en vez de abortar.
+func openAssetState(path string) (*assetState, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("no se pudo crear el directorio %s: %w", dir, err)
+		}
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el lock del estado: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("no se pudo bloquear %s: %w", lock.Name(), err)
+	}
+
+	st := &assetState{path: path, lock: lock, seen: make(map[string]struct{})}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		log.Printf("aviso: no se pudo leer el estado %s, se usa vacío: %v", path, err)
+	default:
+		var sf assetStateFile
+		if err := json.Unmarshal(data, &sf); err != nil {
+			log.Printf("aviso: estado %s corrupto, se usa vacío: %v", path, err)
+			break
+		}
+		for _, a := range sf.Assets {
+			st.seen[a] = struct{}{}
+		}
+	}
+	return st, nil
+}
+
+// save escribe el estado de forma atómica (archivo temporal + rename).
+func (s *assetState) save() error {
+	sf := assetStateFile{Assets: make([]string, 0, len(s.seen))}
+	for a := range s.seen {
+		sf.Assets = append(sf.Assets, a)
+	}
+	sort.Strings(sf.Assets)
+
+	data, err := json.MarshalIndent(sf, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Close libera el lock del estado.
+func (s *assetState) Close() error {
+	unlockFile(s.lock)
+	return s.lock.Close()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+/*****************
+ * Estadísticas por plataforma
+ *****************/
+
+// Stats agrega contadores por plataforma (o cuenta) durante una ejecución con
+// varios trabajos concurrentes. Cada trabajo obtiene su platformStats con
+// platform y lo actualiza sin bloqueos; Stats solo se bloquea para registrar
+// plataformas nuevas o recorrerlas.
+type Stats struct {
+	mu        sync.Mutex
+	platforms []*platformStats // en orden de registro
+	byLabel   map[string]*platformStats
+}
+
+// platformStats son los contadores de un trabajo. Todos sus métodos admiten
+// un receptor nil, que no cuenta nada.
+type platformStats struct {
+	label    string
+	programs atomic.Int64
+	// assets cuenta los activos emitidos por el trabajo, antes de deduplicar.
+	assets   atomic.Int64
+	requests atomic.Int64
+	// errors cuenta las solicitudes fallidas (también las que luego se
+	// reintentaron con éxito) más el fallo final del trabajo, si lo hubo.
+	errors atomic.Int64
+	// truncated indica que alguna paginación del trabajo se cortó en
+	// -max-pages.
+	truncated atomic.Bool
+}
+
+func newStats() *Stats {
+	return &Stats{byLabel: make(map[string]*platformStats)}
+}
+
+// platform devuelve (creándolos si hace falta) los contadores de label.
+func (s *Stats) platform(label string) *platformStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.byLabel[label]
+	if !ok {
+		ps = &platformStats{label: label}
+		s.byLabel[label] = ps
+		s.platforms = append(s.platforms, ps)
+	}
+	return ps
+}
+
+func (ps *platformStats) addPrograms(n int) {
+	if ps != nil {
+		ps.programs.Add(int64(n))
+	}
+}
+
+func (ps *platformStats) addRequest() {
+	if ps != nil {
+		ps.requests.Add(1)
+	}
+}
+
+func (ps *platformStats) addError() {
+	if ps != nil {
+		ps.errors.Add(1)
+	}
+}
+
+func (ps *platformStats) markTruncated() {
+	if ps != nil {
+		ps.truncated.Store(true)
+	}
+}
+
+// wrap cuenta cada activo que el trabajo emite antes de pasarlo a next.
+func (ps *platformStats) wrap(next AssetWriter) AssetWriter {
+	if ps == nil {
+		return next
+	}
+	return assetWriterFunc(func(a Asset) error {
+		ps.assets.Add(1)
+		return next.WriteAsset(a)
+	})
+}
+
+type platformStatsKey struct{}
+
+// withPlatformStats asocia ps al contexto para que platforms.Hooks cuente las
+// solicitudes y errores del trabajo sin cambiar la firma de los fetchers.
+func withPlatformStats(ctx context.Context, ps *platformStats) context.Context {
+	return context.WithValue(ctx, platformStatsKey{}, ps)
+}
+
+// statsFromContext devuelve los contadores del contexto, o nil si no hay.
+func statsFromContext(ctx context.Context) *platformStats {
+	ps, _ := ctx.Value(platformStatsKey{}).(*platformStats)
+	return ps
+}
+
+// print escribe una tabla con los contadores de cada plataforma.
+func (s *Stats) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Plataforma\tProgramas\tActivos\tSolicitudes\tErrores")
+	for _, ps := range s.platforms {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", ps.label, ps.programs.Load(), ps.assets.Load(), ps.requests.Load(), ps.errors.Load())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+/*****************
+ * Almacenamiento en base de datos (-store)
+ *****************/
+
+// storeBackend describe un motor admitido por -store: el driver de
+// database/sql y el esquema que se crea si no existe.
+type storeBackend struct {
+	driver string
+	schema []string
+	// fullDSN pasa -store completo al driver (URLs postgres://) en lugar de
+	// lo que va tras "<motor>:".
+	fullDSN bool
+	// autocommit confirma cada escritura por separado en vez de usar una
+	// transacción por ejecución: en una base compartida, una transacción
+	// larga bloquearía (o haría fallar por deadlock) a las demás ejecuciones
+	// que tocan los mismos activos.
+	autocommit bool
+	// migrations actualizan los almacenes creados por versiones anteriores.
+	migrations []storeMigration
+}
+
+// storeMigration añade una columna nueva a un almacén existente: alter solo
+// se ejecuta si check falla porque la columna no existe.
+type storeMigration struct {
+	check, alter string
+}
+
+// storeBackends asocia el prefijo de -store (<motor>:<dsn>) con su backend.
+// Las consultas de escritura usan marcadores $N y ON CONFLICT, comunes a
+// todos los motores.
+var storeBackends = map[string]storeBackend{
+	"postgres": {driver: "pgx", schema: storeSchema("TIMESTAMPTZ"), migrations: storeMigrations("TIMESTAMPTZ"), fullDSN: true, autocommit: true},
+	"sqlite":   {driver: "sqlite", schema: storeSchema("TIMESTAMP"), migrations: storeMigrations("TIMESTAMP")},
+}
+
+// storeSchema devuelve las tablas de -store; solo cambia el tipo de las
+// marcas de tiempo entre motores.
+func storeSchema(timestamp string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS programs (
+			platform        TEXT NOT NULL,
+			handle          TEXT NOT NULL,
+			offers_bounties BOOLEAN NOT NULL,
+			first_seen      ` + timestamp + ` NOT NULL,
+			last_seen       ` + timestamp + ` NOT NULL,
+			removed_at      ` + timestamp + `,
+			PRIMARY KEY (platform, handle)
+		)`,
+		`CREATE TABLE IF NOT EXISTS assets (
+			platform            TEXT NOT NULL,
+			handle              TEXT NOT NULL,
+			identifier          TEXT NOT NULL,
+			asset_type          TEXT NOT NULL,
+			instruction         TEXT NOT NULL,
+			eligible_for_bounty BOOLEAN NOT NULL,
+			first_seen          ` + timestamp + ` NOT NULL,
+			last_seen           ` + timestamp + ` NOT NULL,
+			removed_at          ` + timestamp + `,
+			PRIMARY KEY (platform, handle, identifier)
+		)`,
+	}
+}
+
+// storeMigrations añade removed_at a los almacenes anteriores a esa columna.
+func storeMigrations(timestamp string) []storeMigration {
+	var m []storeMigration
+	for _, table := range []string{"programs", "assets"} {
+		m = append(m, storeMigration{
+			check: `SELECT removed_at FROM ` + table + ` LIMIT 0`,
+			alter: `ALTER TABLE ` + table + ` ADD COLUMN removed_at ` + timestamp,
+		})
+	}
+	return m
+}
+
+const upsertProgramSQL = `INSERT INTO programs (platform, handle, offers_bounties, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $4)
+ON CONFLICT (platform, handle) DO UPDATE SET
+	offers_bounties = excluded.offers_bounties,
+	last_seen = excluded.last_seen,
+	removed_at = NULL`
+
+const upsertAssetSQL = `INSERT INTO assets (platform, handle, identifier, asset_type, instruction, eligible_for_bounty, first_seen, last_seen)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+ON CONFLICT (platform, handle, identifier) DO UPDATE SET
+	asset_type = excluded.asset_type,
+	instruction = excluded.instruction,
+	eligible_for_bounty = excluded.eligible_for_bounty,
+	last_seen = excluded.last_seen,
+	removed_at = NULL`
+
+// Lo que no se vio en esta ejecución (last_seen anterior) ha salido del scope.
+const (
+	removeProgramAssetsSQL = `UPDATE assets SET removed_at = $1
+WHERE removed_at IS NULL AND last_seen < $1 AND platform = $2 AND handle = $3`
+	removePlatformAssetsSQL = `UPDATE assets SET removed_at = $1
+WHERE removed_at IS NULL AND last_seen < $1 AND platform = $2`
+	removePlatformProgramsSQL = `UPDATE programs SET removed_at = $1
+WHERE removed_at IS NULL AND last_seen < $1 AND platform = $2`
+	// Solo los activos de los programas que acaban de salir del scope.
+	removeRemovedProgramAssetsSQL = `UPDATE assets SET removed_at = $1
+WHERE removed_at IS NULL AND platform = $2 AND handle IN (
+	SELECT handle FROM programs WHERE platform = $2 AND removed_at = $1)`
+)
+
+// assetStore guarda programas y activos con su primera y última aparición y,
+// cuando dejan de aparecer, con la fecha en que salieron del scope.
+// Toda la ejecución comparte la misma marca de tiempo, de modo que last_seen
+// identifica los activos vistos en la última ejecución; salvo en los motores
+// autocommit, además va en una sola transacción.
+type assetStore struct {
+	db *sql.DB
+	// tx es nil en los motores autocommit.
+	tx    *sql.Tx
+	runAt time.Time
+
+	// mu protege programs y err: los programas llegan también por
+	// platforms.Hooks.Program, desde los fetchers en paralelo.
+	mu       sync.Mutex
+	programs map[programKey]bool
+	// err es el primer fallo al guardar un programa desde el hook.
+	err error
+}
+
+// openAssetStore abre (y crea si hace falta) el almacén indicado en -store,
+// p. ej. "sqlite:scopes.db" o "postgres://user@host/sabb".
+func openAssetStore(spec string) (*assetStore, error) {
+	kind, dsn, ok := strings.Cut(spec, ":")
+	kind = strings.ToLower(kind)
+	if kind == "postgresql" {
+		kind = "postgres"
+	}
+	backend, known := storeBackends[kind]
+	if !ok || !known || dsn == "" {
+		return nil, fmt.Errorf("-store %q no válido: se espera sqlite:<archivo> o postgres://...", spec)
+	}
+	if backend.fullDSN {
+		dsn = spec
+	}
+	db, err := sql.Open(backend.driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range backend.schema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("no se pudo preparar el almacén %s: %w", kind, err)
+		}
+	}
+	for _, m := range backend.migrations {
+		if _, err := db.Exec(m.check); err == nil {
+			continue
+		}
+		if _, err := db.Exec(m.alter); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("no se pudo actualizar el almacén %s: %w", kind, err)
+		}
+	}
+	st := &assetStore{db: db, runAt: time.Now().UTC(), programs: make(map[programKey]bool)}
+	if !backend.autocommit {
+		if st.tx, err = db.Begin(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+// wrap guarda cada activo antes de pasarlo a next. No es seguro para uso
+// concurrente; se coloca detrás de syncAssetWriter.
+func (s *assetStore) wrap(next AssetWriter) AssetWriter {
+	return assetWriterFunc(func(a Asset) error {
+		if err := s.save(a); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+		return next.WriteAsset(a)
+	})
+}
+
+func (s *assetStore) exec(query string, args ...any) (sql.Result, error) {
+	if s.tx != nil {
+		return s.tx.Exec(query, args...)
+	}
+	return s.db.Exec(query, args...)
+}
+
+func (s *assetStore) save(a Asset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.upsertProgram(a.Platform, a.Handle, a.OffersBounties); err != nil {
+		return err
+	}
+	_, err := s.exec(upsertAssetSQL, a.Platform, a.Handle, a.Identifier, a.Type, a.Instruction, a.BountyEligible(), s.runAt)
+	return err
+}
+
+// observeProgram guarda p como visto en esta ejecución aunque no tenga
+// activos en scope, para que markRemoved no lo dé por eliminado. Se conecta a
+// platforms.Hooks.Program; el primer error lo devuelve markRemoved.
+func (s *assetStore) observeProgram(p Program) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.upsertProgram(p.Platform, p.Handle, p.OffersBounties); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// upsertProgram guarda el programa la primera vez que aparece en la
+// ejecución. Se llama con s.mu tomado.
+func (s *assetStore) upsertProgram(platform, handle string, offersBounties bool) error {
+	key := programKey{platform, handle}
+	if s.programs[key] {
+		return nil
+	}
+	if _, err := s.exec(upsertProgramSQL, platform, handle, offersBounties, s.runAt); err != nil {
+		return err
+	}
+	s.programs[key] = true
+	return nil
+}
+
+// removalScope indica qué puede dar por salido del scope markRemoved en una
+// plataforma. programs exige que se recorriera su listado completo y sin
+// filtros (-handle, -since, -min-bounty...); assets, que no se filtraran sus
+// activos (-collapse, -instruction-*): lo omitido no salió del scope.
+type removalScope struct {
+	programs, assets bool
+}
+
+// markRemoved marca con removed_at lo que dejó de aparecer en las
+// plataformas de completed, que terminaron sin errores ni cortes de
+// paginación: según su removalScope, los activos de los programas vistos en
+// esta ejecución y los programas que no aparecieron (con todos sus activos).
+func (s *assetStore) markRemoved(completed map[string]removalScope) error {
+	// Un programa que no se pudo guardar parecería eliminado.
+	if s.err != nil {
+		return s.err
+	}
+	for p, scope := range completed {
+		if !scope.programs {
+			continue
+		}
+		if _, err := s.exec(removePlatformProgramsSQL, s.runAt, p); err != nil {
+			return err
+		}
+		query := removePlatformAssetsSQL
+		if !scope.assets {
+			query = removeRemovedProgramAssetsSQL
+		}
+		if _, err := s.exec(query, s.runAt, p); err != nil {
+			return err
+		}
+	}
+	for k := range s.programs {
+		if scope, ok := completed[k.platform]; !ok || scope.programs || !scope.assets {
+			continue
+		}
+		if _, err := s.exec(removeProgramAssetsSQL, s.runAt, k.platform, k.handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close confirma lo guardado y cierra la base de datos. Como la salida, se
+// confirma también si la ejecución terminó antes de tiempo.
+func (s *assetStore) Close() error {
+	var err error
+	if s.tx != nil {
+		err = s.tx.Commit()
+	}
+	if cerr := s.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+/*****************
+ * Transporte HTTP
+ *****************/
+
+// transportOptions ajusta las conexiones del cliente de la API.
+type transportOptions struct {
+	dialTimeout         time.Duration
+	tlsHandshakeTimeout time.Duration
+	// maxIdleConnsPerHost es alto porque casi todas las solicitudes van al
+	// mismo host: con el valor por defecto de Go (2) las conexiones se cierran
+	// y reabren constantemente al trabajar con varias cuentas a la vez.
+	maxIdleConnsPerHost int
+}
+
+// newTransport devuelve un transporte basado en el de Go con los timeouts de
+// conexión y la reutilización de conexiones indicados.
+func newTransport(opts transportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   opts.dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.TLSHandshakeTimeout = opts.tlsHandshakeTimeout
+	t.MaxIdleConnsPerHost = opts.maxIdleConnsPerHost
+	if t.MaxIdleConns < opts.maxIdleConnsPerHost {
+		t.MaxIdleConns = opts.maxIdleConnsPerHost
+	}
+	return t
+}
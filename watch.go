@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+/*****************
+ * Ejecución periódica (-watch)
+ *****************/
+
+// watchLoop llama a fetch cada interval hasta que se cancela ctx (Ctrl-C o
+// SIGTERM). Cada consulta se compara con la anterior, que fetch devuelve; la
+// primera, con -diff si se indicó. Un error en una consulta se registra y se
+// reintenta en la siguiente; solo las credenciales inválidas detienen el
+// bucle, porque no se van a arreglar solas.
+func watchLoop(ctx context.Context, interval time.Duration, fetch func(ctx context.Context, previous *scopeDiff) (*scopeDiff, error)) error {
+	if interval <= 0 {
+		return errors.New("-interval debe ser mayor que cero")
+	}
+	var previous *scopeDiff
+	for {
+		started := time.Now()
+		next, err := fetch(ctx, previous)
+		if ctx.Err() != nil {
+			log.Printf("-watch detenido")
+			return nil
+		}
+		if next != nil {
+			previous = next
+		}
+		if err != nil {
+			if exitCode(err) == exitInvalidCredentials {
+				return err
+			}
+			log.Print(stderrColor.red("ERROR: " + err.Error()))
+		}
+
+		at := started.Add(interval)
+		fmt.Fprintf(os.Stderr, "Siguiente consulta: %s\n", at.Format("2006-01-02 15:04:05"))
+		t := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			log.Printf("-watch detenido")
+			return nil
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/*****************
+ * Notificación por webhook
+ *****************/
+
+// webhookTimeout acota el POST del resumen: un webhook caído no debe retrasar
+// el final de la ejecución.
+const webhookTimeout = 10 * time.Second
+
+// runSummary es el resumen JSON que se envía a -webhook al terminar.
+type runSummary struct {
+	Platforms     []platformSummary `json:"platforms"`
+	TotalPrograms int               `json:"total_programs"`
+	TotalAssets   int               `json:"total_assets"`
+	// NewAssets solo se informa con -state, donde los activos escritos son
+	// exactamente los que no se habían visto antes, o con -diff y -watch, que
+	// también informan de RemovedAssets si la ejecución se completó.
+	NewAssets       *int     `json:"new_assets,omitempty"`
+	RemovedAssets   *int     `json:"removed_assets,omitempty"`
+	Errors          []string `json:"errors,omitempty"`
+	Stopped         bool     `json:"stopped"`
+	DurationSeconds float64  `json:"duration_seconds"`
+}
+
+type platformSummary struct {
+	Name     string `json:"name"`
+	Programs int    `json:"programs"`
+	Assets   int64  `json:"assets"`
+	Requests int64  `json:"requests"`
+	Error    string `json:"error,omitempty"`
+}
+
+// sendWebhook publica el resumen en url. Los fallos solo se registran como
+// aviso: la notificación nunca cambia el resultado de la ejecución.
+func sendWebhook(url string, summary runSummary) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := postJSON(ctx, url, summary); err != nil {
+		log.Printf("aviso: no se pudo notificar al webhook: %v", err)
+	}
+}
+
+// postJSON envía v serializado como JSON por POST a url y devuelve un error si
+// la respuesta no es 2xx.
+func postJSON(ctx context.Context, url string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}